@@ -0,0 +1,285 @@
+// Package cli implements the subcommands of the provider binary used to reproduce what the provider
+// does outside of Terraform.
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/k8s"
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/kubeseal"
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/provider"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// errUsage is returned when the arguments are invalid, the usage has already been printed.
+var errUsage = errors.New("invalid usage")
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, fs *flag.FlagSet, args []string, stdin io.Reader, stdout io.Writer) error
+}
+
+var commands = map[string]command{
+	"seal": {
+		usage: "Seal a secret from the arguments of a sealedsecret resource given as a JSON object, printing its sealed_secret",
+		run:   runSeal,
+	},
+	"fetch-cert": {
+		usage: "Print the certificate of the controller",
+		run:   runFetchCert,
+	},
+	"verify": {
+		usage: "Ask the controller whether it can decrypt a SealedSecret manifest",
+		run:   runVerify,
+	},
+	"inspect": {
+		usage: "Print the name, namespace, scope, type and keys of a SealedSecret manifest as JSON",
+		run:   runInspect,
+	},
+}
+
+// IsCommand reports whether name is a subcommand, as opposed to the binary being launched by
+// Terraform.
+func IsCommand(name string) bool {
+	_, ok := commands[name]
+	return ok || name == "help"
+}
+
+// Run runs the subcommand args[0] and returns the exit code of the process.
+func Run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		usage(stderr)
+		if args[0] == "help" {
+			return 0
+		}
+		return 2
+	}
+
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: %s %s [flags]\n\n%s\n\n", os.Args[0], args[0], cmd.usage)
+		fs.PrintDefaults()
+	}
+
+	err := cmd.run(ctx, fs, args[1:], stdin, stdout)
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	case err != nil:
+		fmt.Fprintf(stderr, "%s: %s\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(w, "usage: %s <command> [flags]\n\nWithout a command the binary serves the provider to Terraform.\n\nCommands:\n", os.Args[0])
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-12s %s\n", name, commands[name].usage)
+	}
+}
+
+// clusterFlags mirror the kubernetes block and controller settings of the provider.
+type clusterFlags struct {
+	host, caFile, certFile, keyFile, token string
+	controllerName, controllerNamespace    string
+}
+
+func (c *clusterFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.host, "host", "", "The hostname (in form of URI) of the Kubernetes API")
+	fs.StringVar(&c.caFile, "cluster-ca-certificate", "", "File of the PEM-encoded root certificates bundle for TLS authentication")
+	fs.StringVar(&c.certFile, "client-certificate", "", "File of the PEM-encoded client certificate for TLS authentication")
+	fs.StringVar(&c.keyFile, "client-key", "", "File of the PEM-encoded client certificate key for TLS authentication")
+	fs.StringVar(&c.token, "token", os.Getenv("SEALEDSECRET_TOKEN"), "Bearer token for authentication to the Kubernetes API, defaults to $SEALEDSECRET_TOKEN")
+	fs.StringVar(&c.controllerName, "controller-name", k8s.DefaultControllerName, "Name of the sealed-secrets controller service")
+	fs.StringVar(&c.controllerNamespace, "controller-namespace", k8s.DefaultControllerNamespace, "Namespace of the sealed-secrets controller service")
+}
+
+func (c *clusterFlags) client() (*k8s.Client, error) {
+	if c.host == "" {
+		return nil, fmt.Errorf("-host is required to reach the controller")
+	}
+	cfg := &k8s.Config{Host: c.host, Token: c.token}
+	for _, f := range []struct {
+		path string
+		dst  *[]byte
+	}{
+		{c.caFile, &cfg.ClusterCACert},
+		{c.certFile, &cfg.ClientCert},
+		{c.keyFile, &cfg.ClientKey},
+	} {
+		if f.path == "" {
+			continue
+		}
+		b, err := os.ReadFile(f.path)
+		if err != nil {
+			return nil, err
+		}
+		*f.dst = b
+	}
+	return k8s.NewClient(cfg)
+}
+
+func (c *clusterFlags) fetchCert(ctx context.Context) ([]byte, error) {
+	client, err := c.client()
+	if err != nil {
+		return nil, err
+	}
+	cert, err := client.Get(ctx, c.controllerName, c.controllerNamespace, "/v1/cert.pem")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := kubeseal.ParsePublicKey(cert); err != nil {
+		return nil, fmt.Errorf("controller returned an invalid certificate: %w", err)
+	}
+	return cert, nil
+}
+
+// readInput reads the file at path, or stdin when path is "-".
+func readInput(path string, stdin io.Reader) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(stdin)
+	}
+	return os.ReadFile(path)
+}
+
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "unexpected arguments: %s\n", strings.Join(fs.Args(), " "))
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+func runSeal(ctx context.Context, fs *flag.FlagSet, args []string, stdin io.Reader, stdout io.Writer) error {
+	var cluster clusterFlags
+	cluster.register(fs)
+	input := fs.String("input", "-", "File of the resource arguments as a JSON object, - for stdin")
+	certFile := fs.String("cert", "", "File of the certificate to seal with, replaces public_key. Fetched from the controller when -host is set")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	arguments, err := readInput(*input, stdin)
+	if err != nil {
+		return err
+	}
+
+	var cert []byte
+	switch {
+	case *certFile != "":
+		if cert, err = os.ReadFile(*certFile); err != nil {
+			return err
+		}
+	case cluster.host != "":
+		if cert, err = cluster.fetchCert(ctx); err != nil {
+			return err
+		}
+	}
+
+	sealed, err := provider.SealResourceJSON(ctx, arguments, string(cert))
+	if err != nil {
+		return err
+	}
+	_, err = stdout.Write(sealed)
+	return err
+}
+
+func runFetchCert(ctx context.Context, fs *flag.FlagSet, args []string, _ io.Reader, stdout io.Writer) error {
+	var cluster clusterFlags
+	cluster.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cert, err := cluster.fetchCert(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = stdout.Write(cert)
+	return err
+}
+
+func runVerify(ctx context.Context, fs *flag.FlagSet, args []string, stdin io.Reader, stdout io.Writer) error {
+	var cluster clusterFlags
+	cluster.register(fs)
+	input := fs.String("input", "-", "File of the SealedSecret manifest, - for stdin")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	manifest, err := readInput(*input, stdin)
+	if err != nil {
+		return err
+	}
+	sealedSecret, err := kubeseal.ParseSealedSecret(manifest)
+	if err != nil {
+		return err
+	}
+	// the controller only decodes JSON
+	body, err := yaml.YAMLToJSON(manifest)
+	if err != nil {
+		return err
+	}
+
+	client, err := cluster.client()
+	if err != nil {
+		return err
+	}
+	if _, err := client.Post(ctx, cluster.controllerName, cluster.controllerNamespace, "/v1/verify", body); err != nil {
+		var status k8sErrors.APIStatus
+		if errors.As(err, &status) && status.Status().Code == http.StatusConflict {
+			return fmt.Errorf("the controller cannot decrypt %s/%s", sealedSecret.Namespace, sealedSecret.Name)
+		}
+		return err
+	}
+	_, err = fmt.Fprintf(stdout, "%s/%s can be decrypted by the controller\n", sealedSecret.Namespace, sealedSecret.Name)
+	return err
+}
+
+func runInspect(_ context.Context, fs *flag.FlagSet, args []string, stdin io.Reader, stdout io.Writer) error {
+	input := fs.String("input", "-", "File of the SealedSecret manifest, - for stdin")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	manifest, err := readInput(*input, stdin)
+	if err != nil {
+		return err
+	}
+	summary, err := kubeseal.Inspect(manifest)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(summary)
+}
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/fakecontroller"
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+const controllerPath = "/api/v1/namespaces/kube-system/services/http:sealed-secrets-controller:/proxy"
+
+func testCert(t *testing.T) []byte {
+	_, cert, err := crypto.GeneratePrivateKeyAndCert(2048, time.Hour, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+}
+
+func run(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := Run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestSealAndInspect(t *testing.T) {
+	certFile := filepath.Join(t.TempDir(), "cert.pem")
+	assert.Nil(t, os.WriteFile(certFile, testCert(t), 0o600))
+
+	code, sealed, stderr := run(`{"name": "name-aa", "namespace": "ns-aa", "scope": "cluster-wide", "string_data": {"keyAA": "valueAA"}}`, "seal", "-cert", certFile)
+	assert.Equal(t, 0, code, stderr)
+	assert.Contains(t, sealed, "kind: SealedSecret")
+
+	code, summary, stderr := run(sealed, "inspect")
+	assert.Equal(t, 0, code, stderr)
+	assert.JSONEq(t, `{"name": "name-aa", "namespace": "ns-aa", "scope": "cluster-wide", "type": "Opaque", "keys": ["keyAA"]}`, summary)
+}
+
+func TestSealErrors(t *testing.T) {
+	code, _, stderr := run(`{}`, "seal", "-cert", "/nonexistent")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "no such file or directory")
+
+	code, _, stderr = run(`{"name": "name-aa", "namespace": "ns-aa", "string_data": {"keyAA": "valueAA"}}`, "seal")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "seal: public_key is required\n", stderr)
+
+	certFile := filepath.Join(t.TempDir(), "cert.pem")
+	assert.Nil(t, os.WriteFile(certFile, testCert(t), 0o600))
+	code, _, stderr = run(`{"name": "name_aa", "namespace": "ns-aa", "string_data": {"keyAA": "valueAA"}}`, "seal", "-cert", certFile)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, `invalid name "name_aa"`)
+
+	code, _, stderr = run(`{"name": "name-aa", "namespace": "ns-aa", "data": {"keyAA": "valueAA"}, "string_data": {"keyAA": "valueBB"}}`, "seal", "-cert", certFile)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, `key "keyAA" is set in both data and string_data`)
+
+	code, _, stderr = run(`{"name": "name-aa", "namespace": "ns-aa", "string_data": {"key/AA": "valueAA"}}`, "seal", "-cert", certFile)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, `string_data: invalid key "key/AA"`)
+
+	code, _, _ = run("", "seal", "extra")
+	assert.Equal(t, 2, code)
+}
+
+func TestFetchCertAndVerify(t *testing.T) {
+	cert := testCert(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET " + controllerPath + "/v1/cert.pem":
+			_, _ = w.Write(cert)
+		case "POST " + controllerPath + "/v1/verify":
+			body, _ := ioutil.ReadAll(r.Body)
+			if !strings.HasPrefix(string(body), "{") {
+				t.Errorf("expected a JSON body, got %s", body)
+			}
+			if strings.Contains(string(body), "name_bb") {
+				w.WriteHeader(http.StatusConflict)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	code, stdout, stderr := run("", "fetch-cert", "-host", server.URL)
+	assert.Equal(t, 0, code, stderr)
+	assert.Equal(t, string(cert), stdout)
+
+	manifest := "apiVersion: bitnami.com/v1alpha1\nkind: SealedSecret\nmetadata:\n  name: %s\n  namespace: ns_aa\nspec:\n  encryptedData:\n    keyAA: AgA=\n"
+
+	code, stdout, stderr = run(strings.ReplaceAll(manifest, "%s", "name_aa"), "verify", "-host", server.URL)
+	assert.Equal(t, 0, code, stderr)
+	assert.Equal(t, "ns_aa/name_aa can be decrypted by the controller\n", stdout)
+
+	code, _, stderr = run(strings.ReplaceAll(manifest, "%s", "name_bb"), "verify", "-host", server.URL)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "verify: the controller cannot decrypt ns_aa/name_bb\n", stderr)
+}
+
+func TestSealWithController(t *testing.T) {
+	controller, err := fakecontroller.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer controller.Close()
+
+	code, sealed, stderr := run(`{"name": "name-aa", "namespace": "ns-aa", "string_data": {"keyAA": "valueAA"}}`, "seal", "-host", controller.URL())
+	assert.Equal(t, 0, code, stderr)
+
+	code, stdout, stderr := run(sealed, "verify", "-host", controller.URL())
+	assert.Equal(t, 0, code, stderr)
+	assert.Equal(t, "ns-aa/name-aa can be decrypted by the controller\n", stdout)
+
+	secret, err := controller.Unseal([]byte(sealed))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("valueAA"), secret.Data["keyAA"])
+}
+
+func TestIsCommand(t *testing.T) {
+	assert.True(t, IsCommand("seal"))
+	assert.True(t, IsCommand("help"))
+	assert.False(t, IsCommand("-debug"))
+}
@@ -0,0 +1,262 @@
+// Package fakecontroller runs a sealed-secrets controller behind an httptest server, reachable
+// through the Kubernetes service proxy paths used by k8s.Client, so that sealing can be tested end
+// to end without a cluster. Its Service can be listed to discover it. Secrets are unsealed with the
+// sealed-secrets code of the controller.
+package fakecontroller
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/k8s"
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+const (
+	keySize  = 2048
+	validFor = 24 * time.Hour
+)
+
+// Controller is a fake sealed-secrets controller. Create one with New and Close it when done.
+type Controller struct {
+	server    *httptest.Server
+	name      string
+	namespace string
+
+	mu   sync.Mutex
+	keys map[string]*rsa.PrivateKey
+	cert *x509.Certificate
+}
+
+// Option configures a Controller.
+type Option func(*Controller)
+
+// WithService serves the controller as the Service name in namespace instead of
+// k8s.DefaultControllerName in k8s.DefaultControllerNamespace.
+func WithService(name, namespace string) Option {
+	return func(c *Controller) {
+		c.name = name
+		c.namespace = namespace
+	}
+}
+
+// New starts a controller with a freshly generated key.
+func New(opts ...Option) (*Controller, error) {
+	c := &Controller{
+		name:      k8s.DefaultControllerName,
+		namespace: k8s.DefaultControllerNamespace,
+		keys:      map[string]*rsa.PrivateKey{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if err := c.Rotate(); err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(c.proxyPath("/v1/cert.pem"), c.handleCert)
+	mux.HandleFunc(c.proxyPath("/v1/verify"), c.handleVerify)
+	mux.HandleFunc(c.proxyPath("/v1/rotate"), c.handleRotate)
+	mux.HandleFunc("/api/v1/services", c.handleServices)
+	c.server = httptest.NewServer(mux)
+	return c, nil
+}
+
+// URL is the address of the fake API server.
+func (c *Controller) URL() string {
+	return c.server.URL
+}
+
+// Config returns a k8s.Config connecting to the fake API server.
+func (c *Controller) Config() *k8s.Config {
+	return &k8s.Config{Host: c.server.URL}
+}
+
+// Close shuts the server down.
+func (c *Controller) Close() {
+	c.server.Close()
+}
+
+// CertPEM returns the certificate the controller currently seals with, as served on /v1/cert.pem.
+func (c *Controller) CertPEM() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw})
+}
+
+// Rotate generates a new key, like a key renewal of the controller. Secrets sealed with previous
+// keys can still be unsealed.
+func (c *Controller) Rotate() error {
+	key, cert, err := crypto.GeneratePrivateKeyAndCert(keySize, validFor, "sealed-secret")
+	if err != nil {
+		return fmt.Errorf("unable to generate a key: %w", err)
+	}
+	fingerprint, err := crypto.PublicKeyFingerprint(&key.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys[fingerprint] = key
+	c.cert = cert
+	return nil
+}
+
+// Unseal decrypts a SealedSecret manifest, in YAML or JSON, with any key of the controller.
+func (c *Controller) Unseal(manifest []byte) (*v1.Secret, error) {
+	sealedSecret, err := decode(manifest)
+	if err != nil {
+		return nil, err
+	}
+	return c.unseal(sealedSecret)
+}
+
+func (c *Controller) unseal(sealedSecret *ssv1alpha1.SealedSecret) (*v1.Secret, error) {
+	c.mu.Lock()
+	keys := make(map[string]*rsa.PrivateKey, len(c.keys))
+	for k, v := range c.keys {
+		keys[k] = v
+	}
+	c.mu.Unlock()
+
+	return sealedSecret.Unseal(scheme.Codecs, keys)
+}
+
+// rotate seals the secret of a SealedSecret manifest again with the current key.
+func (c *Controller) rotate(manifest []byte) ([]byte, error) {
+	sealedSecret, err := decode(manifest)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := c.unseal(sealedSecret)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt secret: %w", err)
+	}
+
+	c.mu.Lock()
+	pk := c.cert.PublicKey.(*rsa.PublicKey)
+	c.mu.Unlock()
+
+	resealed, err := ssv1alpha1.NewSealedSecret(scheme.Codecs, pk, secret)
+	if err != nil {
+		return nil, fmt.Errorf("unable to seal secret: %w", err)
+	}
+	// unlike the controller, answer with a manifest that can be decoded on its own
+	resealed.SetGroupVersionKind(ssv1alpha1.SchemeGroupVersion.WithKind("SealedSecret"))
+	return json.Marshal(resealed)
+}
+
+func (c *Controller) proxyPath(path string) string {
+	return fmt.Sprintf("/api/v1/namespaces/%s/services/http:%s:/proxy%s", c.namespace, c.name, path)
+}
+
+func (c *Controller) handleCert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-pem-file")
+	_, _ = w.Write(c.CertPEM())
+}
+
+func (c *Controller) handleVerify(w http.ResponseWriter, r *http.Request) {
+	content, ok := readBody(w, r)
+	if !ok {
+		return
+	}
+	sealedSecret, err := decode(content)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// like the controller, a secret that cannot be unsealed is a conflict rather than an error
+	if _, err := c.unseal(sealedSecret); err != nil {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (c *Controller) handleRotate(w http.ResponseWriter, r *http.Request) {
+	content, ok := readBody(w, r)
+	if !ok {
+		return
+	}
+	resealed, err := c.rotate(content)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(resealed)
+}
+
+// handleServices lists the controller Service, labelled like by the Helm chart, when it matches the
+// label selector of the request.
+func (c *Controller) handleServices(w http.ResponseWriter, r *http.Request) {
+	selector, err := labels.Parse(r.URL.Query().Get("labelSelector"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	service := v1.Service{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      c.name,
+			Namespace: c.namespace,
+			Labels:    map[string]string{"app.kubernetes.io/name": "sealed-secrets"},
+		},
+		Spec: v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 8080}}},
+	}
+	list := v1.ServiceList{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceList"}}
+	if selector.Matches(labels.Set(service.Labels)) {
+		list.Items = append(list.Items, service)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(list)
+}
+
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return nil, false
+	}
+	content, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return nil, false
+	}
+	return content, true
+}
+
+func decode(manifest []byte) (*ssv1alpha1.SealedSecret, error) {
+	if strings.TrimSpace(string(manifest)) == "" {
+		return nil, fmt.Errorf("empty sealed secret manifest")
+	}
+	object, err := runtime.Decode(scheme.Codecs.UniversalDecoder(ssv1alpha1.SchemeGroupVersion), manifest)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode sealed secret: %w", err)
+	}
+	sealedSecret, ok := object.(*ssv1alpha1.SealedSecret)
+	if !ok {
+		return nil, fmt.Errorf("unexpected resource type %s", object.GetObjectKind().GroupVersionKind().String())
+	}
+	return sealedSecret, nil
+}
@@ -0,0 +1,120 @@
+package fakecontroller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/k8s"
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/kubeseal"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newController(t *testing.T, opts ...Option) (*Controller, *k8s.Client) {
+	c, err := New(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+	client, err := k8s.NewClient(c.Config())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, client
+}
+
+func seal(ctx context.Context, t *testing.T, client *k8s.Client, name, namespace string) []byte {
+	sealed, err := kubeseal.NewSealer(kubeseal.FetchPK(client, name, namespace)).Seal(ctx, v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "name-aa", Namespace: "ns-aa"},
+		StringData: map[string]string{"keyAA": "valueAA"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sealed
+}
+
+func TestSealAndUnseal(t *testing.T) {
+	ctx := context.Background()
+	c, client := newController(t)
+
+	cert, err := client.Get(ctx, k8s.DefaultControllerName, k8s.DefaultControllerNamespace, "/v1/cert.pem")
+	assert.Nil(t, err)
+	assert.Equal(t, c.CertPEM(), cert)
+
+	sealed := seal(ctx, t, client, k8s.DefaultControllerName, k8s.DefaultControllerNamespace)
+	secret, err := c.Unseal(sealed)
+	assert.Nil(t, err)
+	assert.Equal(t, "name-aa", secret.Name)
+	assert.Equal(t, "ns-aa", secret.Namespace)
+	assert.Equal(t, []byte("valueAA"), secret.Data["keyAA"])
+
+	// another controller cannot unseal it
+	other, _ := newController(t)
+	_, err = other.Unseal(sealed)
+	assert.NotNil(t, err)
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	_, client := newController(t)
+	sealed := seal(ctx, t, client, k8s.DefaultControllerName, k8s.DefaultControllerNamespace)
+
+	_, err := client.Post(ctx, k8s.DefaultControllerName, k8s.DefaultControllerNamespace, "/v1/verify", sealed)
+	assert.Nil(t, err)
+
+	_, otherClient := newController(t)
+	_, err = otherClient.Post(ctx, k8s.DefaultControllerName, k8s.DefaultControllerNamespace, "/v1/verify", sealed)
+	var status k8sErrors.APIStatus
+	assert.True(t, errors.As(err, &status))
+	assert.Equal(t, int32(http.StatusConflict), status.Status().Code)
+
+	_, err = client.Post(ctx, k8s.DefaultControllerName, k8s.DefaultControllerNamespace, "/v1/verify", []byte("kind: Secret"))
+	assert.NotNil(t, err)
+}
+
+func TestRotate(t *testing.T) {
+	ctx := context.Background()
+	c, client := newController(t)
+	sealed := seal(ctx, t, client, k8s.DefaultControllerName, k8s.DefaultControllerNamespace)
+	before := c.CertPEM()
+
+	assert.Nil(t, c.Rotate())
+	assert.NotEqual(t, before, c.CertPEM())
+
+	// secrets sealed with a previous key are still unsealed
+	_, err := c.Unseal(sealed)
+	assert.Nil(t, err)
+
+	resealed, err := client.Post(ctx, k8s.DefaultControllerName, k8s.DefaultControllerNamespace, "/v1/rotate", sealed)
+	assert.Nil(t, err)
+	parsed, err := kubeseal.ParseSealedSecret(resealed)
+	assert.Nil(t, err)
+	assert.Equal(t, "name-aa", parsed.Name)
+	assert.NotEqual(t, sealed, resealed)
+
+	secret, err := c.Unseal(resealed)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("valueAA"), secret.Data["keyAA"])
+}
+
+func TestWithService(t *testing.T) {
+	ctx := context.Background()
+	c, client := newController(t, WithService("sealed-secrets", "sealed-secrets"))
+
+	name, namespace, err := client.DiscoverController(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "sealed-secrets", name)
+	assert.Equal(t, "sealed-secrets", namespace)
+
+	_, err = client.Get(ctx, k8s.DefaultControllerName, k8s.DefaultControllerNamespace, "/v1/cert.pem")
+	assert.NotNil(t, err)
+
+	sealed := seal(ctx, t, client, name, namespace)
+	_, err = c.Unseal(sealed)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,188 @@
+package k8s
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+// ValidateKey checks that key is accepted by the API server as a key of a secret.
+func ValidateKey(key string) error {
+	if errs := validation.IsConfigMapKey(key); len(errs) > 0 {
+		return fmt.Errorf("invalid key %q: %s", key, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// ParseEnvFile parses a dotenv file into key/value pairs. Lines may be prefixed with export, values
+// may be single quoted (taken literally) or double quoted (\n, \r, \t, \\, \" and \$ are unescaped)
+// and quoted values may span several lines. Unquoted values end at a " #" comment. Variables are
+// not expanded.
+func ParseEnvFile(content []byte) (map[string]string, error) {
+	lines := strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+	values := make(map[string]string)
+
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if rest := strings.TrimPrefix(line, "export"); rest != line && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			line = strings.TrimSpace(rest)
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNo)
+		}
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, fmt.Errorf("line %d: missing key", lineNo)
+		}
+		value = strings.TrimLeft(value, " \t")
+
+		if value != "" && (value[0] == '"' || value[0] == '\'') {
+			quote := value[0]
+			rest := value[1:]
+			var b strings.Builder
+			for {
+				end := closingQuote(rest, quote)
+				if end >= 0 {
+					b.WriteString(rest[:end])
+					if after := strings.TrimSpace(rest[end+1:]); after != "" && !strings.HasPrefix(after, "#") {
+						return nil, fmt.Errorf("line %d: unexpected %q after the closing quote", i+1, after)
+					}
+					break
+				}
+				b.WriteString(rest)
+				b.WriteByte('\n')
+				i++
+				if i == len(lines) {
+					return nil, fmt.Errorf("line %d: unterminated quoted value", lineNo)
+				}
+				rest = lines[i]
+			}
+			value = b.String()
+			if quote == '"' {
+				value = unescapeDoubleQuoted(value)
+			}
+		} else {
+			if idx := strings.Index(value, " #"); idx >= 0 {
+				value = value[:idx]
+			}
+			if idx := strings.Index(value, "\t#"); idx >= 0 {
+				value = value[:idx]
+			}
+			value = strings.TrimSpace(value)
+		}
+
+		if _, ok := values[key]; ok {
+			return nil, fmt.Errorf("line %d: duplicate key %q", lineNo, key)
+		}
+		values[key] = value
+	}
+
+	return values, nil
+}
+
+// closingQuote returns the index of the first quote in s which is not escaped, or -1. Single
+// quoted values have no escapes.
+func closingQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if quote == '"' {
+				i++
+			}
+		case quote:
+			return i
+		}
+	}
+	return -1
+}
+
+func unescapeDoubleQuoted(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '\\', '"', '$':
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// ParseJSONFile parses a JSON object into key/value pairs. Values must be strings, numbers or
+// booleans, the latter two are kept as written. Duplicate keys are rejected.
+func ParseJSONFile(content []byte) (map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil, fmt.Errorf("expected an object of keys to values")
+	}
+
+	values := make(map[string]string)
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := t.(string)
+		if _, ok := values[key]; ok {
+			return nil, fmt.Errorf("duplicate key %q", key)
+		}
+
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		switch v := v.(type) {
+		case string:
+			values[key] = v
+		case json.Number:
+			values[key] = v.String()
+		case bool:
+			values[key] = strconv.FormatBool(v)
+		default:
+			return nil, fmt.Errorf("value of key %q must be a string, number or boolean", key)
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected content after the object")
+	}
+
+	return values, nil
+}
+
+// ParseYAMLFile parses a YAML mapping into key/value pairs with the same rules as ParseJSONFile.
+func ParseYAMLFile(content []byte) (map[string]string, error) {
+	j, err := yaml.YAMLToJSONStrict(content)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJSONFile(j)
+}
@@ -0,0 +1,149 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	tests := []struct {
+		Name           string
+		Content        string
+		ExpectedValues map[string]string
+		ExpectedErr    string
+	}{
+		{
+			Name: "plain values, comments and export prefixes",
+			Content: `# database
+DB_HOST=db.example.com
+export DB_PORT = 5432
+DB_NAME=app # inline comment
+DB_OPTS=sslmode=require#not-a-comment
+
+EMPTY=
+`,
+			ExpectedValues: map[string]string{
+				"DB_HOST": "db.example.com",
+				"DB_PORT": "5432",
+				"DB_NAME": "app",
+				"DB_OPTS": "sslmode=require#not-a-comment",
+				"EMPTY":   "",
+			},
+		},
+		{
+			Name: "quoted values",
+			Content: `SINGLE='literal \n $HOME # kept'
+DOUBLE="tab\there \"quoted\" \$HOME" # comment
+SPACES="  padded  "
+`,
+			ExpectedValues: map[string]string{
+				"SINGLE": `literal \n $HOME # kept`,
+				"DOUBLE": "tab\there \"quoted\" $HOME",
+				"SPACES": "  padded  ",
+			},
+		},
+		{
+			Name:    "multi-line values",
+			Content: "KEY=\"-----BEGIN KEY-----\nabc\n-----END KEY-----\"\r\nOTHER='a\nb'\n",
+			ExpectedValues: map[string]string{
+				"KEY":   "-----BEGIN KEY-----\nabc\n-----END KEY-----",
+				"OTHER": "a\nb",
+			},
+		},
+		{
+			Name:        "duplicate key",
+			Content:     "A=1\nexport A=2\n",
+			ExpectedErr: `line 2: duplicate key "A"`,
+		},
+		{
+			Name:        "missing separator",
+			Content:     "A=1\nB\n",
+			ExpectedErr: "line 2: expected KEY=VALUE",
+		},
+		{
+			Name:        "unterminated quote",
+			Content:     "A=1\nB=\"abc\nC=3\n",
+			ExpectedErr: "line 2: unterminated quoted value",
+		},
+		{
+			Name:        "content after closing quote",
+			Content:     "A='abc' def\n",
+			ExpectedErr: `line 1: unexpected "def" after the closing quote`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			values, err := ParseEnvFile([]byte(tc.Content))
+			if tc.ExpectedErr != "" {
+				assert.EqualError(t, err, tc.ExpectedErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.ExpectedValues, values)
+		})
+	}
+}
+
+func TestParseJSONFile(t *testing.T) {
+	tests := []struct {
+		Name           string
+		Content        string
+		ExpectedValues map[string]string
+		ExpectedErr    string
+	}{
+		{
+			Name:           "scalars",
+			Content:        `{"user": "admin", "port": 5432, "ratio": 1.50, "debug": false}`,
+			ExpectedValues: map[string]string{"user": "admin", "port": "5432", "ratio": "1.50", "debug": "false"},
+		},
+		{
+			Name:        "duplicate key",
+			Content:     `{"a": "1", "a": "2"}`,
+			ExpectedErr: `duplicate key "a"`,
+		},
+		{
+			Name:        "nested object",
+			Content:     `{"a": {"b": "c"}}`,
+			ExpectedErr: `value of key "a" must be a string, number or boolean`,
+		},
+		{
+			Name:        "not an object",
+			Content:     `["a"]`,
+			ExpectedErr: "expected an object of keys to values",
+		},
+		{
+			Name:        "trailing content",
+			Content:     `{"a": "1"} {"b": "2"}`,
+			ExpectedErr: "unexpected content after the object",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			values, err := ParseJSONFile([]byte(tc.Content))
+			if tc.ExpectedErr != "" {
+				assert.EqualError(t, err, tc.ExpectedErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.ExpectedValues, values)
+		})
+	}
+}
+
+func TestParseYAMLFile(t *testing.T) {
+	values, err := ParseYAMLFile([]byte("user: admin\nport: 5432\ncert: |\n  line1\n  line2\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"user": "admin", "port": "5432", "cert": "line1\nline2\n"}, values)
+
+	_, err = ParseYAMLFile([]byte("a: 1\na: 2\n"))
+	assert.NotNil(t, err)
+}
+
+func TestValidateKey(t *testing.T) {
+	assert.Nil(t, ValidateKey("DB_HOST"))
+	assert.Nil(t, ValidateKey(".dockerconfigjson"))
+	assert.EqualError(t, ValidateKey("has space"), `invalid key "has space": a valid config key must consist of alphanumeric characters, '-', '_' or '.' (e.g. 'key.name',  or 'KEY_NAME',  or 'key-name', regex used for validation is '[-._a-zA-Z0-9]+')`)
+}
@@ -0,0 +1,73 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// Name and namespace of the controller Service in the manifests of the sealed-secrets releases,
+// used when none is configured.
+const (
+	DefaultControllerName      = "sealed-secrets-controller"
+	DefaultControllerNamespace = "kube-system"
+)
+
+// ControllerSelectors are the labels of the controller Service in the manifests of the
+// sealed-secrets releases and in its Helm chart.
+var ControllerSelectors = []string{
+	"name=sealed-secrets-controller",
+	"app.kubernetes.io/name=sealed-secrets",
+}
+
+var (
+	ErrControllerNotFound  = errors.New("no sealed-secrets controller service found")
+	ErrAmbiguousController = errors.New("more than one sealed-secrets controller service found")
+)
+
+// DiscoverController finds the Service of the sealed-secrets controller across namespaces by the
+// labels of ControllerSelectors. Services not serving plain HTTP, like the metrics Service of the
+// Helm chart, are ignored. It fails when no or more than one Service matches.
+func (c *Client) DiscoverController(ctx context.Context) (string, string, error) {
+	found := map[string]v1.Service{}
+	for _, selector := range ControllerSelectors {
+		services, err := c.ListServices(ctx, "", selector)
+		if err != nil {
+			return "", "", err
+		}
+		for _, s := range services {
+			if servesHTTP(s) {
+				found[s.Namespace+"/"+s.Name] = s
+			}
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return "", "", fmt.Errorf("%w with labels %s", ErrControllerNotFound, strings.Join(ControllerSelectors, " or "))
+	case 1:
+		for _, s := range found {
+			return s.Name, s.Namespace, nil
+		}
+	}
+	names := make([]string, 0, len(found))
+	for k := range found {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return "", "", fmt.Errorf("%w: %s", ErrAmbiguousController, strings.Join(names, ", "))
+}
+
+// servesHTTP reports whether the Service has the port the API server proxies http: requests to.
+func servesHTTP(s v1.Service) bool {
+	for _, p := range s.Spec.Ports {
+		if p.Name == "" || p.Name == "http" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func service(name, namespace, port string) v1.Service {
+	return v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       v1.ServiceSpec{Ports: []v1.ServicePort{{Name: port, Port: 8080}}},
+	}
+}
+
+func servicesMock(t *testing.T, bySelector map[string][]v1.Service) roundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "/api/v1/services", req.URL.Path)
+		list := v1.ServiceList{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceList"},
+			Items:    bySelector[req.URL.Query().Get("labelSelector")],
+		}
+		body, err := json.Marshal(list)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       ioutil.NopCloser(strings.NewReader(string(body))),
+		}, nil
+	}
+}
+
+func TestDiscoverController(t *testing.T) {
+	tests := []struct {
+		Name              string
+		Services          map[string][]v1.Service
+		ExpectedName      string
+		ExpectedNamespace string
+		ExpectedErr       string
+	}{
+		{
+			Name: "legacy manifests",
+			Services: map[string][]v1.Service{
+				"name=sealed-secrets-controller": {service("sealed-secrets-controller", "kube-system", "")},
+			},
+			ExpectedName:      "sealed-secrets-controller",
+			ExpectedNamespace: "kube-system",
+		},
+		{
+			Name: "helm chart with metrics",
+			Services: map[string][]v1.Service{
+				"app.kubernetes.io/name=sealed-secrets": {
+					service("sealed-secrets", "sealed-secrets", "http"),
+					service("sealed-secrets-metrics", "sealed-secrets", "metrics"),
+				},
+			},
+			ExpectedName:      "sealed-secrets",
+			ExpectedNamespace: "sealed-secrets",
+		},
+		{
+			Name: "matched by both selectors",
+			Services: map[string][]v1.Service{
+				"name=sealed-secrets-controller":        {service("name-aa", "ns-aa", "")},
+				"app.kubernetes.io/name=sealed-secrets": {service("name-aa", "ns-aa", "")},
+			},
+			ExpectedName:      "name-aa",
+			ExpectedNamespace: "ns-aa",
+		},
+		{
+			Name:        "not found",
+			Services:    map[string][]v1.Service{},
+			ExpectedErr: "no sealed-secrets controller service found with labels name=sealed-secrets-controller or app.kubernetes.io/name=sealed-secrets",
+		},
+		{
+			Name: "ambiguous",
+			Services: map[string][]v1.Service{
+				"name=sealed-secrets-controller":        {service("sealed-secrets-controller", "kube-system", "")},
+				"app.kubernetes.io/name=sealed-secrets": {service("sealed-secrets", "sealed-secrets", "http")},
+			},
+			ExpectedErr: "more than one sealed-secrets controller service found: kube-system/sealed-secrets-controller, sealed-secrets/sealed-secrets",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			c, err := NewClient(&Config{Transport: servicesMock(t, tc.Services)})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			name, namespace, err := c.DiscoverController(context.Background())
+			if tc.ExpectedErr != "" {
+				assert.EqualError(t, err, tc.ExpectedErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.ExpectedName, name)
+			assert.Equal(t, tc.ExpectedNamespace, namespace)
+		})
+	}
+
+	c, err := NewClient(&Config{Transport: servicesMock(t, nil)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = c.DiscoverController(context.Background())
+	assert.True(t, errors.Is(err, ErrControllerNotFound))
+}
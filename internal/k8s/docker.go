@@ -0,0 +1,50 @@
+package k8s
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// DockerRegistry holds the credentials for a single registry in a .dockerconfigjson secret.
+type DockerRegistry struct {
+	Server   string
+	Username string
+	Password string
+	Email    string
+}
+
+type dockerConfigEntry struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Auth     string `json:"auth"`
+}
+
+type dockerConfigJSON struct {
+	Auths map[string]dockerConfigEntry `json:"auths"`
+}
+
+var ErrEmptyDockerServer = errors.New("docker registry server cannot be empty")
+
+// DockerConfigJSON renders the registries as a ~/.docker/config.json document, the format expected
+// under the .dockerconfigjson key of a kubernetes.io/dockerconfigjson secret.
+func DockerConfigJSON(registries []DockerRegistry) ([]byte, error) {
+	cfg := dockerConfigJSON{Auths: make(map[string]dockerConfigEntry)}
+	for _, r := range registries {
+		if r.Server == "" {
+			return nil, ErrEmptyDockerServer
+		}
+		if _, ok := cfg.Auths[r.Server]; ok {
+			return nil, fmt.Errorf("docker registry %q is defined more than once", r.Server)
+		}
+		cfg.Auths[r.Server] = dockerConfigEntry{
+			Username: r.Username,
+			Password: r.Password,
+			Email:    r.Email,
+			Auth:     base64.StdEncoding.EncodeToString([]byte(r.Username + ":" + r.Password)),
+		}
+	}
+	return json.Marshal(cfg)
+}
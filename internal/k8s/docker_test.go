@@ -0,0 +1,92 @@
+package k8s
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestDockerConfigJSON(t *testing.T) {
+	cfg, err := DockerConfigJSON([]DockerRegistry{
+		{Server: "ghcr.io", Username: "user_aaa", Password: "pass_aaa", Email: "aaa@example.com"},
+		{Server: "docker.io", Username: "user_bbb", Password: "pass_bbb"},
+	})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"auths":{
+		"ghcr.io":{"username":"user_aaa","password":"pass_aaa","email":"aaa@example.com","auth":"dXNlcl9hYWE6cGFzc19hYWE="},
+		"docker.io":{"username":"user_bbb","password":"pass_bbb","auth":"dXNlcl9iYmI6cGFzc19iYmI="}
+	}}`, string(cfg))
+
+	_, err = DockerConfigJSON([]DockerRegistry{{Server: "ghcr.io"}, {Server: "ghcr.io"}})
+	assert.EqualError(t, err, `docker registry "ghcr.io" is defined more than once`)
+
+	_, err = DockerConfigJSON([]DockerRegistry{{Username: "user_aaa"}})
+	assert.Equal(t, ErrEmptyDockerServer, err)
+}
+
+func TestCreateSecretWithDockerRegistries(t *testing.T) {
+	registries := []DockerRegistry{{Server: "ghcr.io", Username: "user_aaa", Password: "pass_aaa"}}
+
+	secret, err := CreateSecret(&SecretManifest{
+		Name:             "name_aaa",
+		Namespace:        "ns_aaa",
+		Type:             string(v1.SecretTypeDockerConfigJson),
+		DockerRegistries: registries,
+	})
+	assert.Nil(t, err)
+
+	var cfg dockerConfigJSON
+	assert.Nil(t, json.Unmarshal(secret.Data[v1.DockerConfigJsonKey], &cfg))
+	assert.Equal(t, "dXNlcl9hYWE6cGFzc19hYWE=", cfg.Auths["ghcr.io"].Auth)
+
+	_, err = CreateSecret(&SecretManifest{
+		Name:             "name_aaa",
+		Namespace:        "ns_aaa",
+		Type:             string(v1.SecretTypeOpaque),
+		DockerRegistries: registries,
+	})
+	assert.EqualError(t, err, "docker registries can only be used with secrets of type kubernetes.io/dockerconfigjson, got Opaque")
+
+	_, err = CreateSecret(&SecretManifest{
+		Name:             "name_aaa",
+		Namespace:        "ns_aaa",
+		Type:             string(v1.SecretTypeDockerConfigJson),
+		StringData:       map[string]string{v1.DockerConfigJsonKey: "{}"},
+		DockerRegistries: registries,
+	})
+	assert.EqualError(t, err, `key ".dockerconfigjson" is set in both string_data and docker_registries`)
+
+	_, err = CreateSecret(&SecretManifest{
+		Name:             "name_aaa",
+		Namespace:        "ns_aaa",
+		Type:             string(v1.SecretTypeDockerConfigJson),
+		Data:             map[string]interface{}{v1.DockerConfigJsonKey: "{}"},
+		DockerRegistries: registries,
+	})
+	assert.EqualError(t, err, `key ".dockerconfigjson" is set in both data and docker_registries`)
+
+	_, err = CreateSecret(&SecretManifest{
+		Name:             "name_aaa",
+		Namespace:        "ns_aaa",
+		Type:             string(v1.SecretTypeDockerConfigJson),
+		DataBase64:       map[string]string{v1.DockerConfigJsonKey: "e30="},
+		DockerRegistries: registries,
+	})
+	assert.EqualError(t, err, `key ".dockerconfigjson" is set in both data_base64 and docker_registries`)
+
+	// other data is encoded like for any other type
+	secret, err = CreateSecret(&SecretManifest{
+		Name:             "name_aaa",
+		Namespace:        "ns_aaa",
+		Type:             string(v1.SecretTypeDockerConfigJson),
+		Data:             map[string]interface{}{"keyAA": "valueAA"},
+		DataBase64:       map[string]string{"keyBB": "dmFsdWVCQg=="},
+		DockerRegistries: registries,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("valueAA"), secret.Data["keyAA"])
+	assert.Equal(t, []byte("valueBB"), secret.Data["keyBB"])
+	assert.Nil(t, json.Unmarshal(secret.Data[v1.DockerConfigJsonKey], &cfg))
+}
@@ -7,6 +7,9 @@ import (
 	"net/http"
 	"time"
 
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/net"
 	"k8s.io/apimachinery/pkg/util/wait"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
@@ -27,6 +30,7 @@ type Client struct {
 type Config struct {
 	Host                                 string
 	ClusterCACert, ClientCert, ClientKey []byte
+	Token                                string
 	Transport                            http.RoundTripper
 }
 
@@ -42,6 +46,7 @@ func NewClient(cfg *Config) (*Client, error) {
 	restCfg.CAData = cfg.ClusterCACert
 	restCfg.CertData = cfg.ClientCert
 	restCfg.KeyData = cfg.ClientKey
+	restCfg.BearerToken = cfg.Token
 	if cfg.Transport != nil {
 		restCfg.Transport = cfg.Transport
 	}
@@ -68,3 +73,38 @@ func (c *Client) Get(ctx context.Context, controllerName, controllerNamespace, p
 	}
 	return b, nil
 }
+
+// Post sends body to path of the controller service through the API server proxy.
+func (c *Client) Post(ctx context.Context, controllerName, controllerNamespace, path string, body []byte) ([]byte, error) {
+	b, err := c.RestClient.RESTClient().Post().
+		Namespace(controllerNamespace).
+		Resource("services").
+		SubResource("proxy").
+		Name(net.JoinSchemeNamePort("http", controllerName, "")).
+		Suffix(path).
+		Body(body).
+		DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("request to k8s cluster failed: %w", err)
+	}
+	return b, nil
+}
+
+// GetSecret reads the Secret name in namespace from the cluster.
+func (c *Client) GetSecret(ctx context.Context, namespace, name string) (*v1.Secret, error) {
+	secret, err := c.RestClient.Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read secret %s/%s: %w", namespace, name, err)
+	}
+	return secret, nil
+}
+
+// ListServices lists the Services matching labelSelector in namespace, in every namespace when
+// namespace is empty.
+func (c *Client) ListServices(ctx context.Context, namespace, labelSelector string) ([]v1.Service, error) {
+	list, err := c.RestClient.Services(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list services matching %s: %w", labelSelector, err)
+	}
+	return list.Items, nil
+}
@@ -2,8 +2,10 @@ package k8s
 
 import (
 	"context"
+	"errors"
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	"net/http"
 	"strings"
 	"testing"
@@ -75,3 +77,108 @@ func TestGet(t *testing.T) {
 		})
 	}
 }
+
+func TestGetSecret(t *testing.T) {
+	tests := []struct {
+		Name         string
+		Mock         roundTripFunc
+		ExpectedData map[string][]byte
+		ExpectedErr  string
+	}{
+		{
+			Name: "happy day",
+			Mock: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, "/api/v1/namespaces/ns_aaa/secrets/name_aaa", req.URL.Path)
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": []string{"application/json"}},
+					Body:       ioutil.NopCloser(strings.NewReader(`{"apiVersion":"v1","kind":"Secret","metadata":{"name":"name_aaa","namespace":"ns_aaa"},"data":{"key":"dmFsdWU="}}`)),
+				}, nil
+			}),
+			ExpectedData: map[string][]byte{"key": []byte("value")},
+		},
+		{
+			Name: "not found",
+			Mock: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusNotFound,
+					Header:     http.Header{"Content-Type": []string{"application/json"}},
+					Body:       ioutil.NopCloser(strings.NewReader(`{"apiVersion":"v1","kind":"Status","status":"Failure","message":"secrets \"name_aaa\" not found","reason":"NotFound","code":404}`)),
+				}, nil
+			}),
+			ExpectedErr: "unable to read secret ns_aaa/name_aaa: secrets \"name_aaa\" not found",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			c, err := NewClient(&Config{Transport: tc.Mock})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			secret, err := c.GetSecret(context.Background(), "ns_aaa", "name_aaa")
+			if tc.ExpectedErr != "" {
+				assert.EqualError(t, err, tc.ExpectedErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.ExpectedData, secret.Data)
+		})
+	}
+}
+
+func TestPost(t *testing.T) {
+	tests := []struct {
+		Name         string
+		Mock         roundTripFunc
+		ExpectedBody string
+		ExpectedErr  string
+	}{
+		{
+			Name: "happy day",
+			Mock: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, http.MethodPost, req.Method)
+				assert.Equal(t, "/api/v1/namespaces/ns_aaa/services/http:name_aaa:/proxy/v1/verify", req.URL.Path)
+				body, err := ioutil.ReadAll(req.Body)
+				assert.Nil(t, err)
+				assert.Equal(t, "manifest", string(body))
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(strings.NewReader("ok")),
+				}, nil
+			}),
+			ExpectedBody: "ok",
+		},
+		{
+			Name: "conflict",
+			Mock: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusConflict,
+					Body:       ioutil.NopCloser(strings.NewReader("")),
+				}, nil
+			}),
+			ExpectedErr: "request to k8s cluster failed: the server reported a conflict (post services http:name_aaa:)",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			c, err := NewClient(&Config{Transport: tc.Mock})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body, err := c.Post(context.Background(), "name_aaa", "ns_aaa", "/v1/verify", []byte("manifest"))
+			if tc.ExpectedErr != "" {
+				assert.EqualError(t, err, tc.ExpectedErr)
+				var status k8sErrors.APIStatus
+				assert.True(t, errors.As(err, &status))
+				assert.Equal(t, int32(http.StatusConflict), status.Status().Code)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.ExpectedBody, string(body))
+		})
+	}
+}
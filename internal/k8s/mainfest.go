@@ -1,83 +1,148 @@
 package k8s
 
 import (
-	"bytes"
 	"encoding/base64"
 	"errors"
 	"fmt"
-	"html/template"
 
 	v1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/client-go/kubernetes/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-const secretManifestTmpl = `
-apiVersion: v1
-kind: Secret
-metadata:
-  creationTimestamp: null
-  name: {{ .Name }}
-  namespace: {{ .Namespace }}
-  {{ if .Annotations }}
-  annotations:
-    {{- range $key, $value := .Annotations }}
-    {{ $key }}: "{{ $value -}}"
-    {{ end }}
-  {{ end }}
-{{ if .Data }}
-data:
-  {{- range $key, $value := .Data }}
-  {{ $key }}: {{ $value -}}
-  {{ end }}
-{{ end }}
-{{ if .StringData }}
-stringData:
-  {{- range $key, $value := .StringData }}
-  {{ $key }}: {{ $value -}}
-  {{ end }}
-{{ end }}
-type: {{ .Type }}
-`
-
 type SecretManifest struct {
 	Name        string
 	Namespace   string
 	Type        string
 	Data        map[string]interface{}
 	StringData  map[string]string
+	Labels      map[string]string
 	Annotations map[string]string
+	// DockerRegistries, when set, are rendered into the .dockerconfigjson key of a
+	// kubernetes.io/dockerconfigjson secret.
+	DockerRegistries []DockerRegistry
+	// DataBase64 holds base64 encoded values which are decoded for validation and
+	// otherwise passed through untouched, so binary content survives.
+	DataBase64 map[string]string
+	Immutable  bool
 }
 
 var ErrEmptyData = errors.New("secret manifest Data and StringData cannot be empty")
 
 func CreateSecret(sm *SecretManifest) (v1.Secret, error) {
-	if len(sm.Data) == 0 && len(sm.StringData) == 0 {
+	if len(sm.DockerRegistries) > 0 {
+		if err := addDockerConfigJSON(sm); err != nil {
+			return v1.Secret{}, err
+		}
+	}
+
+	if len(sm.Data) == 0 && len(sm.StringData) == 0 && len(sm.DataBase64) == 0 {
 		return v1.Secret{}, ErrEmptyData
 	}
 
-	// if it is a .docker/config.json file then the data should already be base64 encoded
-	if sm.Type != "kubernetes.io/dockerconfigjson" {
-		sm.Data = b64EncodeMapValue(sm.Data)
+	sm.Data = b64EncodeMapValue(sm.Data)
+	if len(sm.DataBase64) > 0 {
+		if err := addDataBase64(sm); err != nil {
+			return v1.Secret{}, err
+		}
 	}
-	secretManifestYAML := new(bytes.Buffer)
 
-	t, err := template.New("secretManifestTmpl").Parse(secretManifestTmpl)
-	if err != nil {
-		return v1.Secret{}, err
+	// The secret is built directly rather than rendered and decoded, so that no value can break
+	// the manifest or end up in a decoding error.
+	secret := v1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        sm.Name,
+			Namespace:   sm.Namespace,
+			Labels:      copyMap(sm.Labels),
+			Annotations: copyMap(sm.Annotations),
+		},
+		Type:       v1.SecretType(sm.Type),
+		StringData: copyMap(sm.StringData),
 	}
-	if err := t.Execute(secretManifestYAML, sm); err != nil {
-		return v1.Secret{}, err
+	if len(sm.Data) > 0 {
+		secret.Data = make(map[string][]byte, len(sm.Data))
+		for k, v := range sm.Data {
+			decoded, err := base64.StdEncoding.DecodeString(fmt.Sprintf("%v", v))
+			if err != nil {
+				return v1.Secret{}, fmt.Errorf("value of key %q is not valid base64: %w", k, err)
+			}
+			secret.Data[k] = decoded
+		}
+	}
+	if sm.Immutable {
+		immutable := true
+		secret.Immutable = &immutable
 	}
 
-	var secret v1.Secret
-	if err := runtime.DecodeInto(scheme.Codecs.UniversalDecoder(), secretManifestYAML.Bytes(), &secret); err != nil {
+	if err := ValidateSecret(&secret); err != nil {
 		return v1.Secret{}, err
 	}
 
 	return secret, nil
 }
 
+func addDockerConfigJSON(sm *SecretManifest) error {
+	if sm.Type != string(v1.SecretTypeDockerConfigJson) {
+		return fmt.Errorf("docker registries can only be used with secrets of type %s, got %s", v1.SecretTypeDockerConfigJson, sm.Type)
+	}
+	if _, ok := sm.Data[v1.DockerConfigJsonKey]; ok {
+		return fmt.Errorf("key %q is set in both data and docker_registries", v1.DockerConfigJsonKey)
+	}
+	if _, ok := sm.StringData[v1.DockerConfigJsonKey]; ok {
+		return fmt.Errorf("key %q is set in both string_data and docker_registries", v1.DockerConfigJsonKey)
+	}
+	if _, ok := sm.DataBase64[v1.DockerConfigJsonKey]; ok {
+		return fmt.Errorf("key %q is set in both data_base64 and docker_registries", v1.DockerConfigJsonKey)
+	}
+
+	cfg, err := DockerConfigJSON(sm.DockerRegistries)
+	if err != nil {
+		return err
+	}
+
+	// added as data_base64 so that data is encoded like for any other type
+	d := make(map[string]string, len(sm.DataBase64)+1)
+	for k, v := range sm.DataBase64 {
+		d[k] = v
+	}
+	d[v1.DockerConfigJsonKey] = base64.StdEncoding.EncodeToString(cfg)
+	sm.DataBase64 = d
+	return nil
+}
+
+func addDataBase64(sm *SecretManifest) error {
+	d := make(map[string]interface{}, len(sm.Data)+len(sm.DataBase64))
+	for k, v := range sm.Data {
+		d[k] = v
+	}
+	for k, v := range sm.DataBase64 {
+		if _, ok := d[k]; ok {
+			return fmt.Errorf("key %q is set in both data and data_base64", k)
+		}
+		if _, ok := sm.StringData[k]; ok {
+			return fmt.Errorf("key %q is set in both string_data and data_base64", k)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return fmt.Errorf("value of key %q is not valid base64: %w", k, err)
+		}
+		d[k] = base64.StdEncoding.EncodeToString(decoded)
+	}
+	sm.Data = d
+	return nil
+}
+
+func copyMap(m map[string]string) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
 func b64EncodeMapValue(m map[string]interface{}) map[string]interface{} {
 	result := map[string]interface{}{}
 	for key, value := range m {
@@ -68,3 +68,90 @@ func TestCreateSecret(t *testing.T) {
 	}
 
 }
+
+func TestCreateSecretWithDataBase64(t *testing.T) {
+	binary := []byte{0x00, 0xff, 0xfe, 0x0a}
+
+	secret, err := CreateSecret(&SecretManifest{
+		Name:       "name_aaa",
+		Namespace:  "ns_aaa",
+		Type:       "Opaque",
+		DataBase64: map[string]string{"keystore.p12": "AP/+Cg=="},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, binary, secret.Data["keystore.p12"])
+
+	_, err = CreateSecret(&SecretManifest{
+		Name:       "name_aaa",
+		Namespace:  "ns_aaa",
+		Type:       "Opaque",
+		DataBase64: map[string]string{"keystore.p12": "not base64!"},
+	})
+	assert.ErrorContains(t, err, `value of key "keystore.p12" is not valid base64`)
+
+	_, err = CreateSecret(&SecretManifest{
+		Name:       "name_aaa",
+		Namespace:  "ns_aaa",
+		Type:       "Opaque",
+		Data:       map[string]interface{}{"keystore.p12": "aaa"},
+		DataBase64: map[string]string{"keystore.p12": "AP/+Cg=="},
+	})
+	assert.EqualError(t, err, `key "keystore.p12" is set in both data and data_base64`)
+
+	_, err = CreateSecret(&SecretManifest{
+		Name:       "name_aaa",
+		Namespace:  "ns_aaa",
+		Type:       "Opaque",
+		StringData: map[string]string{"keystore.p12": "aaa"},
+		DataBase64: map[string]string{"keystore.p12": "AP/+Cg=="},
+	})
+	assert.EqualError(t, err, `key "keystore.p12" is set in both string_data and data_base64`)
+}
+
+func TestCreateSecretWithSpecialCharacters(t *testing.T) {
+	value := "value: \"aaa\"\n- {{ bbb }}\n"
+
+	secret, err := CreateSecret(&SecretManifest{
+		Name:        "name_aaa",
+		Namespace:   "ns_aaa",
+		Type:        "Opaque",
+		Data:        map[string]interface{}{"data": value},
+		StringData:  map[string]string{"string_data": value},
+		Labels:      map[string]string{"label": "true"},
+		Annotations: map[string]string{"annotation": value},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(value), secret.Data["data"])
+	assert.Equal(t, value, secret.StringData["string_data"])
+	assert.Equal(t, "true", secret.Labels["label"])
+	assert.Equal(t, value, secret.Annotations["annotation"])
+}
+
+func TestCreateSecretErrorsDoNotEchoValues(t *testing.T) {
+	_, err := CreateSecret(&SecretManifest{
+		Name:       "name_aaa",
+		Namespace:  "ns_aaa",
+		Type:       "Opaque",
+		DataBase64: map[string]string{"keyAA": "secret_aaa"},
+	})
+	assert.ErrorContains(t, err, `value of key "keyAA" is not valid base64`)
+	assert.NotContains(t, err.Error(), "secret_aaa")
+}
+
+// Values used to be HTML escaped while rendering the manifest, turning & into &amp;.
+func TestCreateSecretDoesNotEscapeHTML(t *testing.T) {
+	value := `<a href="x">&'</a>`
+
+	secret, err := CreateSecret(&SecretManifest{
+		Name:        "name_aaa",
+		Namespace:   "ns_aaa",
+		Type:        "Opaque",
+		Data:        map[string]interface{}{"data": value},
+		StringData:  map[string]string{"string_data": value},
+		Annotations: map[string]string{"annotation": value},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(value), secret.Data["data"])
+	assert.Equal(t, value, secret.StringData["string_data"])
+	assert.Equal(t, value, secret.Annotations["annotation"])
+}
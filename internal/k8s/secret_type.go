@@ -0,0 +1,93 @@
+package k8s
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// ValidateSecret checks that a secret carries the keys and annotations the API server
+// requires for its type, so that a secret which would be rejected is never sealed.
+func ValidateSecret(secret *v1.Secret) error {
+	switch secret.Type {
+	case v1.SecretTypeTLS:
+		if err := requireKeys(secret, v1.TLSCertKey, v1.TLSPrivateKeyKey); err != nil {
+			return err
+		}
+		return validateTLS(secretValue(secret, v1.TLSCertKey), secretValue(secret, v1.TLSPrivateKeyKey))
+	case v1.SecretTypeBasicAuth:
+		if !hasKey(secret, v1.BasicAuthUsernameKey) && !hasKey(secret, v1.BasicAuthPasswordKey) {
+			return fmt.Errorf("secret of type %s must contain at least one of %q or %q", secret.Type, v1.BasicAuthUsernameKey, v1.BasicAuthPasswordKey)
+		}
+	case v1.SecretTypeSSHAuth:
+		return requireKeys(secret, v1.SSHAuthPrivateKey)
+	case v1.SecretTypeServiceAccountToken:
+		if secret.Annotations[v1.ServiceAccountNameKey] == "" {
+			return fmt.Errorf("secret of type %s must have the %q annotation", secret.Type, v1.ServiceAccountNameKey)
+		}
+	case v1.SecretTypeDockercfg:
+		if err := requireKeys(secret, v1.DockerConfigKey); err != nil {
+			return err
+		}
+		return validateJSON(secret, v1.DockerConfigKey)
+	case v1.SecretTypeDockerConfigJson:
+		if err := requireKeys(secret, v1.DockerConfigJsonKey); err != nil {
+			return err
+		}
+		return validateJSON(secret, v1.DockerConfigJsonKey)
+	}
+	return nil
+}
+
+func hasKey(secret *v1.Secret, key string) bool {
+	if _, ok := secret.StringData[key]; ok {
+		return true
+	}
+	_, ok := secret.Data[key]
+	return ok
+}
+
+// secretValue returns the value of key the way the API server will see it, with
+// stringData taking precedence over data.
+func secretValue(secret *v1.Secret, key string) []byte {
+	if v, ok := secret.StringData[key]; ok {
+		return []byte(v)
+	}
+	return secret.Data[key]
+}
+
+func requireKeys(secret *v1.Secret, keys ...string) error {
+	for _, key := range keys {
+		if !hasKey(secret, key) {
+			return fmt.Errorf("secret of type %s must contain the %q key", secret.Type, key)
+		}
+	}
+	return nil
+}
+
+func validateJSON(secret *v1.Secret, key string) error {
+	var v map[string]interface{}
+	if err := json.Unmarshal(secretValue(secret, key), &v); err != nil {
+		return fmt.Errorf("%q of secret type %s is not valid JSON: %w", key, secret.Type, err)
+	}
+	return nil
+}
+
+func validateTLS(certPEM, keyPEM []byte) error {
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("invalid %s/%s pair: %w", v1.TLSCertKey, v1.TLSPrivateKeyKey, err)
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("unable to parse %s: %w", v1.TLSCertKey, err)
+	}
+	if time.Now().After(leaf.NotAfter) {
+		return fmt.Errorf("%s expired at %s", v1.TLSCertKey, leaf.NotAfter.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
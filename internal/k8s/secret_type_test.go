@@ -0,0 +1,135 @@
+package k8s
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func generateKeyPair(t *testing.T, notAfter time.Time) ([]byte, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		NotBefore:    notAfter.Add(-48 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return certPEM, keyPEM
+}
+
+func TestValidateSecret(t *testing.T) {
+	validCert, validKey := generateKeyPair(t, time.Now().Add(24*time.Hour))
+	expiredCert, expiredKey := generateKeyPair(t, time.Now().Add(-24*time.Hour))
+	_, otherKey := generateKeyPair(t, time.Now().Add(24*time.Hour))
+
+	tests := []struct {
+		Name        string
+		Secret      v1.Secret
+		ExpectedErr string
+	}{
+		{
+			Name:   "opaque secrets are not checked",
+			Secret: v1.Secret{Type: v1.SecretTypeOpaque},
+		},
+		{
+			Name: "valid tls",
+			Secret: v1.Secret{
+				Type: v1.SecretTypeTLS,
+				Data: map[string][]byte{v1.TLSCertKey: validCert, v1.TLSPrivateKeyKey: validKey},
+			},
+		},
+		{
+			Name: "tls missing key",
+			Secret: v1.Secret{
+				Type: v1.SecretTypeTLS,
+				Data: map[string][]byte{v1.TLSCertKey: validCert},
+			},
+			ExpectedErr: `secret of type kubernetes.io/tls must contain the "tls.key" key`,
+		},
+		{
+			Name: "tls cert and key mismatch",
+			Secret: v1.Secret{
+				Type:       v1.SecretTypeTLS,
+				Data:       map[string][]byte{v1.TLSCertKey: validCert},
+				StringData: map[string]string{v1.TLSPrivateKeyKey: string(otherKey)},
+			},
+			ExpectedErr: "invalid tls.crt/tls.key pair: tls: private key does not match public key",
+		},
+		{
+			Name: "tls expired",
+			Secret: v1.Secret{
+				Type: v1.SecretTypeTLS,
+				Data: map[string][]byte{v1.TLSCertKey: expiredCert, v1.TLSPrivateKeyKey: expiredKey},
+			},
+			ExpectedErr: "tls.crt expired at",
+		},
+		{
+			Name: "basic-auth with only a password",
+			Secret: v1.Secret{
+				Type:       v1.SecretTypeBasicAuth,
+				StringData: map[string]string{v1.BasicAuthPasswordKey: "hunter2"},
+			},
+		},
+		{
+			Name:        "basic-auth without credentials",
+			Secret:      v1.Secret{Type: v1.SecretTypeBasicAuth, StringData: map[string]string{"user": "a"}},
+			ExpectedErr: `secret of type kubernetes.io/basic-auth must contain at least one of "username" or "password"`,
+		},
+		{
+			Name:        "ssh-auth without private key",
+			Secret:      v1.Secret{Type: v1.SecretTypeSSHAuth, StringData: map[string]string{"ssh-publickey": "a"}},
+			ExpectedErr: `secret of type kubernetes.io/ssh-auth must contain the "ssh-privatekey" key`,
+		},
+		{
+			Name: "service-account-token with annotation",
+			Secret: v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{v1.ServiceAccountNameKey: "default"}},
+				Type:       v1.SecretTypeServiceAccountToken,
+			},
+		},
+		{
+			Name:        "service-account-token without annotation",
+			Secret:      v1.Secret{Type: v1.SecretTypeServiceAccountToken},
+			ExpectedErr: `secret of type kubernetes.io/service-account-token must have the "kubernetes.io/service-account.name" annotation`,
+		},
+		{
+			Name: "dockerconfigjson with invalid json",
+			Secret: v1.Secret{
+				Type: v1.SecretTypeDockerConfigJson,
+				Data: map[string][]byte{v1.DockerConfigJsonKey: []byte("{")},
+			},
+			ExpectedErr: `".dockerconfigjson" of secret type kubernetes.io/dockerconfigjson is not valid JSON`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := ValidateSecret(&tc.Secret)
+			if tc.ExpectedErr == "" {
+				assert.Nil(t, err)
+				return
+			}
+			if assert.NotNil(t, err) {
+				assert.Contains(t, err.Error(), tc.ExpectedErr)
+			}
+		})
+	}
+}
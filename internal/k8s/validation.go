@@ -0,0 +1,45 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ValidateName checks that name is accepted by the API server as the name of a secret, a DNS-1123
+// subdomain.
+func ValidateName(name string) error {
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fmt.Errorf("invalid name %q: %s", name, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// ValidateNamespace checks that namespace is accepted by the API server as a namespace, a DNS-1123
+// label.
+func ValidateNamespace(namespace string) error {
+	if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+		return fmt.Errorf("invalid namespace %q: %s", namespace, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// ValidateSecretSize checks the total size of the values of the secret against the limit of the
+// API server. stringData counts as it is merged into data.
+func ValidateSecretSize(secret *v1.Secret) error {
+	size := 0
+	for k, v := range secret.Data {
+		if _, ok := secret.StringData[k]; !ok {
+			size += len(v)
+		}
+	}
+	for _, v := range secret.StringData {
+		size += len(v)
+	}
+	if size > v1.MaxSecretSize {
+		return fmt.Errorf("secret values take %d bytes, more than the %d bytes allowed by the API server", size, v1.MaxSecretSize)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestValidateName(t *testing.T) {
+	assert.Nil(t, ValidateName("name-aa.example"))
+	assert.ErrorContains(t, ValidateName("name_aa"), `invalid name "name_aa"`)
+	assert.Error(t, ValidateName(""))
+	assert.Error(t, ValidateName(strings.Repeat("a", 254)))
+}
+
+func TestValidateNamespace(t *testing.T) {
+	assert.Nil(t, ValidateNamespace("ns-aa"))
+	assert.ErrorContains(t, ValidateNamespace("ns.aa"), `invalid namespace "ns.aa"`)
+	assert.Error(t, ValidateNamespace("NS"))
+	assert.Error(t, ValidateNamespace(strings.Repeat("a", 64)))
+}
+
+func TestValidateSecretSize(t *testing.T) {
+	half := strings.Repeat("a", v1.MaxSecretSize/2)
+
+	assert.Nil(t, ValidateSecretSize(&v1.Secret{
+		Data:       map[string][]byte{"keyAA": []byte(half)},
+		StringData: map[string]string{"keyBB": half},
+	}))
+	// stringData replaces the value of data
+	assert.Nil(t, ValidateSecretSize(&v1.Secret{
+		Data:       map[string][]byte{"keyAA": []byte(half), "keyBB": []byte(half)},
+		StringData: map[string]string{"keyBB": half},
+	}))
+	assert.ErrorContains(t, ValidateSecretSize(&v1.Secret{
+		Data:       map[string][]byte{"keyAA": []byte(half)},
+		StringData: map[string]string{"keyBB": half + "a"},
+	}), "more than the 1048576 bytes allowed")
+}
@@ -0,0 +1,49 @@
+package kubeseal
+
+import (
+	"bytes"
+	"fmt"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Output formats for Bundle
+const (
+	BundleFormatYAML = "yaml"
+	BundleFormatList = "list"
+)
+
+// Bundle combines encoded SealedSecret manifests into a single multi-document YAML stream, or into
+// a v1/List holding all of them. The order of manifests is kept as given.
+func Bundle(manifests [][]byte, format string) ([]byte, error) {
+	switch format {
+	case BundleFormatYAML:
+		var buf bytes.Buffer
+		for i, m := range manifests {
+			if i > 0 {
+				buf.WriteString("---\n")
+			}
+			buf.Write(m)
+			if !bytes.HasSuffix(m, []byte("\n")) {
+				buf.WriteString("\n")
+			}
+		}
+		return buf.Bytes(), nil
+	case BundleFormatList:
+		items := make([]interface{}, 0, len(manifests))
+		for _, m := range manifests {
+			var item map[string]interface{}
+			if err := yaml.Unmarshal(m, &item); err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return yaml.Marshal(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "List",
+			"items":      items,
+		})
+	default:
+		return nil, fmt.Errorf("bundle format must be one of %s or %s, given %s", BundleFormatYAML, BundleFormatList, format)
+	}
+}
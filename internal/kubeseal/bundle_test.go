@@ -0,0 +1,69 @@
+package kubeseal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBundle(t *testing.T) {
+	manifests := [][]byte{
+		[]byte("apiVersion: bitnami.com/v1alpha1\nkind: SealedSecret\nmetadata:\n  name: aa\n"),
+		[]byte("apiVersion: bitnami.com/v1alpha1\nkind: SealedSecret\nmetadata:\n  name: bb"),
+	}
+
+	tests := []struct {
+		Name           string
+		Format         string
+		ExpectedOutput string
+		ExpectedErr    string
+	}{
+		{
+			Name:   "multi-document yaml",
+			Format: BundleFormatYAML,
+			ExpectedOutput: `apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: aa
+---
+apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: bb
+`,
+		},
+		{
+			Name:   "list",
+			Format: BundleFormatList,
+			ExpectedOutput: `apiVersion: v1
+items:
+- apiVersion: bitnami.com/v1alpha1
+  kind: SealedSecret
+  metadata:
+    name: aa
+- apiVersion: bitnami.com/v1alpha1
+  kind: SealedSecret
+  metadata:
+    name: bb
+kind: List
+`,
+		},
+		{
+			Name:        "unknown format",
+			Format:      "json",
+			ExpectedErr: "bundle format must be one of yaml or list, given json",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			out, err := Bundle(manifests, tc.Format)
+			if tc.ExpectedErr != "" {
+				assert.EqualError(t, err, tc.ExpectedErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.ExpectedOutput, string(out))
+		})
+	}
+}
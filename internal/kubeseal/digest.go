@@ -0,0 +1,34 @@
+package kubeseal
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// KeyDigests returns the sorted keys of the secret along with an HMAC-SHA256 of every value keyed
+// by salt. The digests tell whether a value changed without revealing it, as long as the salt
+// stays private. Like kubeseal, stringData overrides data.
+func KeyDigests(secret *v1.Secret, salt []byte) ([]string, map[string]string) {
+	values := make(map[string][]byte, len(secret.Data)+len(secret.StringData))
+	for k, v := range secret.Data {
+		values[k] = v
+	}
+	for k, v := range secret.StringData {
+		values[k] = []byte(v)
+	}
+
+	keys := make([]string, 0, len(values))
+	digests := make(map[string]string, len(values))
+	for k, v := range values {
+		mac := hmac.New(sha256.New, salt)
+		mac.Write(v)
+		keys = append(keys, k)
+		digests[k] = hex.EncodeToString(mac.Sum(nil))
+	}
+	sort.Strings(keys)
+	return keys, digests
+}
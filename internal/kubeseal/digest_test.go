@@ -0,0 +1,29 @@
+package kubeseal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestKeyDigests(t *testing.T) {
+	secret := &v1.Secret{
+		Data:       map[string][]byte{"keyBB": []byte("valueBB"), "keyAA": []byte("valueAA")},
+		StringData: map[string]string{"keyCC": "valueCC", "keyBB": "valueAA"},
+	}
+
+	keys, digests := KeyDigests(secret, []byte("salt_aa"))
+	assert.Equal(t, []string{"keyAA", "keyBB", "keyCC"}, keys)
+	assert.Len(t, digests, 3)
+	// stringData overrides data
+	assert.Equal(t, digests["keyAA"], digests["keyBB"])
+	assert.NotEqual(t, digests["keyAA"], digests["keyCC"])
+	assert.NotContains(t, digests["keyAA"], "valueAA")
+
+	_, again := KeyDigests(secret, []byte("salt_aa"))
+	assert.Equal(t, digests, again)
+
+	_, salted := KeyDigests(secret, []byte("salt_bb"))
+	assert.NotEqual(t, digests["keyAA"], salted["keyAA"])
+}
@@ -0,0 +1,36 @@
+package kubeseal
+
+import "sort"
+
+// Summary describes a SealedSecret without its ciphertext.
+type Summary struct {
+	Name      string   `json:"name"`
+	Namespace string   `json:"namespace"`
+	Scope     string   `json:"scope"`
+	Type      string   `json:"type"`
+	Keys      []string `json:"keys"`
+}
+
+// Inspect decodes a SealedSecret manifest into its Summary, the keys of its encryptedData are
+// sorted.
+func Inspect(manifest []byte) (*Summary, error) {
+	sealedSecret, err := ParseSealedSecret(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(sealedSecret.Spec.EncryptedData))
+	for k := range sealedSecret.Spec.EncryptedData {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	scope := sealedSecret.Scope()
+
+	return &Summary{
+		Name:      sealedSecret.Name,
+		Namespace: sealedSecret.Namespace,
+		Scope:     scope.String(),
+		Type:      string(sealedSecret.Spec.Template.Type),
+		Keys:      keys,
+	}, nil
+}
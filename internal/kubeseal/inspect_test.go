@@ -0,0 +1,37 @@
+package kubeseal
+
+import (
+	"testing"
+
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/k8s"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInspect(t *testing.T) {
+	pk, err := ParsePublicKey([]byte(pem))
+	assert.Nil(t, err)
+
+	secret, err := k8s.CreateSecret(&k8s.SecretManifest{
+		Name:        "name_aa",
+		Namespace:   "ns_aa",
+		Type:        "Opaque",
+		StringData:  map[string]string{"keyBB": "valueBB", "keyAA": "valueAA"},
+		Annotations: map[string]string{"sealedsecrets.bitnami.com/namespace-wide": "true"},
+	})
+	assert.Nil(t, err)
+	manifest, err := SealSecret(secret, pk)
+	assert.Nil(t, err)
+
+	summary, err := Inspect(manifest)
+	assert.Nil(t, err)
+	assert.Equal(t, &Summary{
+		Name:      "name_aa",
+		Namespace: "ns_aa",
+		Scope:     "namespace-wide",
+		Type:      "Opaque",
+		Keys:      []string{"keyAA", "keyBB"},
+	}, summary)
+
+	_, err = Inspect([]byte("apiVersion: v1\nkind: Secret\nmetadata:\n  name: aa\n"))
+	assert.EqualError(t, err, "expected a SealedSecret manifest, got Secret")
+}
@@ -8,31 +8,34 @@ import (
 	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
 	v1 "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
-	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/util/cert"
 )
 
 type PKResolverFunc = func(ctx context.Context) (*rsa.PublicKey, error)
 
+// ParsePublicKey returns the RSA public key of the first certificate in a PEM bundle.
+func ParsePublicKey(certPEM []byte) (*rsa.PublicKey, error) {
+	certs, err := cert.ParseCertsPEM(certPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	pk, ok := certs[0].PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("expected public key, got: %v", certs[0].PublicKey)
+	}
+	return pk, nil
+}
+
 func FetchPK(c k8s.Clienter, controllerName, controllerNamespace string) PKResolverFunc {
 	doReq := func(ctx context.Context) (*rsa.PublicKey, error) {
 		resp, err := c.Get(ctx, controllerName, controllerNamespace, "/v1/cert.pem")
 		if err != nil {
 			return nil, err
 		}
-		certs, err := cert.ParseCertsPEM(resp)
-		if err != nil {
-			return nil, err
-		}
-
-		pk, ok := certs[0].PublicKey.(*rsa.PublicKey)
-		if !ok {
-			err = fmt.Errorf("expected public key, got: %v", certs[0].PublicKey)
-		}
-		return pk, nil
+		return ParsePublicKey(resp)
 	}
 
 	var publicKey *rsa.PublicKey
@@ -49,32 +52,39 @@ func FetchPK(c k8s.Clienter, controllerName, controllerNamespace string) PKResol
 	}
 }
 
-func SealSecret(secret v1.Secret, pk *rsa.PublicKey) ([]byte, error) {
-	codecs := scheme.Codecs
+// ObjectMeta holds labels and annotations for the SealedSecret object itself, as opposed to the
+// metadata of the Secret it unseals to.
+type ObjectMeta struct {
+	Labels      map[string]string
+	Annotations map[string]string
+}
 
-	// Strip read-only server-side ObjectMeta (if present)
-	secret.SetSelfLink("")
-	secret.SetUID("")
-	secret.SetResourceVersion("")
-	secret.Generation = 0
-	secret.SetCreationTimestamp(metav1.Time{})
-	secret.SetDeletionTimestamp(nil)
-	secret.DeletionGracePeriodSeconds = nil
+// SealSecret seals the secret into a YAML SealedSecret manifest.
+func SealSecret(secret v1.Secret, pk *rsa.PublicKey) ([]byte, error) {
+	return SealSecretWithMeta(secret, pk, ObjectMeta{})
+}
 
-	sealedSecret, err := ssv1alpha1.NewSealedSecret(codecs, pk, &secret)
-	if err != nil {
-		return nil, fmt.Errorf("unable to seal secret: %w", err)
-	}
+// SealSecretWithMeta seals the secret like SealSecret and applies meta to the metadata of the
+// resulting SealedSecret. Scope annotations derived from the secret always take precedence.
+func SealSecretWithMeta(secret v1.Secret, pk *rsa.PublicKey, meta ObjectMeta) ([]byte, error) {
+	return NewSealer(StaticKey(pk), WithObjectMeta(meta)).Seal(context.Background(), secret)
+}
 
-	prettyEnc, err := prettyEncoder(codecs, runtime.ContentTypeYAML, ssv1alpha1.SchemeGroupVersion)
-	if err != nil {
-		return nil, err
+func applyObjectMeta(sealedSecret *ssv1alpha1.SealedSecret, meta ObjectMeta, scope ssv1alpha1.SealingScope) {
+	if len(meta.Labels) > 0 {
+		labels := make(map[string]string, len(meta.Labels))
+		for k, v := range meta.Labels {
+			labels[k] = v
+		}
+		sealedSecret.Labels = labels
 	}
-	encodedSealedSecret, err := runtime.Encode(prettyEnc, sealedSecret)
-	if err != nil {
-		return nil, err
+	if len(meta.Annotations) > 0 {
+		annotations := make(map[string]string, len(meta.Annotations)+1)
+		for k, v := range meta.Annotations {
+			annotations[k] = v
+		}
+		sealedSecret.Annotations = ssv1alpha1.UpdateScopeAnnotations(annotations, scope)
 	}
-	return encodedSealedSecret, nil
 }
 
 func prettyEncoder(codecs runtimeserializer.CodecFactory, mediaType string, gv runtime.GroupVersioner) (runtime.Encoder, error) {
@@ -181,3 +181,91 @@ func TestRequestIsRetriedOnRetryableError(t *testing.T) {
 		})
 	}
 }
+
+func TestSealSecretImmutable(t *testing.T) {
+	sm := k8s.SecretManifest{
+		Name:       "name_aa",
+		Namespace:  "ns_aa",
+		Type:       "Opaque",
+		StringData: map[string]string{"keyAA": "valueAA"},
+		Immutable:  true,
+	}
+
+	m := K8sClientMock{}
+	m.On(getFunc, context.Background(), "name", "ns", "/v1/cert.pem").Return(pem, nil)
+	pk, err := FetchPK(&m, "name", "ns")(context.Background())
+	assert.Nil(t, err)
+
+	secret, err := k8s.CreateSecret(&sm)
+	assert.Nil(t, err)
+	sealedSecretRaw, err := SealSecret(secret, pk)
+	assert.Nil(t, err)
+
+	actualSS := struct {
+		Kind string `yaml:"kind"`
+		Spec struct {
+			EncryptedData map[string]string `yaml:"encryptedData"`
+			Template      struct {
+				Immutable bool `yaml:"immutable"`
+			} `yaml:"template"`
+		} `yaml:"spec"`
+	}{}
+
+	err = yaml.Unmarshal(sealedSecretRaw, &actualSS)
+	assert.Nil(t, err)
+
+	assert.Equal(t, "SealedSecret", actualSS.Kind)
+	assert.True(t, actualSS.Spec.Template.Immutable)
+	assert.Contains(t, actualSS.Spec.EncryptedData, "keyAA")
+}
+
+func TestSealSecretWithMeta(t *testing.T) {
+	sm := k8s.SecretManifest{
+		Name:        "name_aa",
+		Namespace:   "ns_aa",
+		Type:        "Opaque",
+		StringData:  map[string]string{"keyAA": "valueAA"},
+		Labels:      map[string]string{"app": "template_aa"},
+		Annotations: map[string]string{"sealedsecrets.bitnami.com/namespace-wide": "true"},
+	}
+
+	m := K8sClientMock{}
+	m.On(getFunc, context.Background(), "name", "ns", "/v1/cert.pem").Return(pem, nil)
+	pk, err := FetchPK(&m, "name", "ns")(context.Background())
+	assert.Nil(t, err)
+
+	secret, err := k8s.CreateSecret(&sm)
+	assert.Nil(t, err)
+	sealedSecretRaw, err := SealSecretWithMeta(secret, pk, ObjectMeta{
+		Labels: map[string]string{"app.kubernetes.io/managed-by": "terraform"},
+		Annotations: map[string]string{
+			"argocd.argoproj.io/sync-wave":             "-1",
+			"sealedsecrets.bitnami.com/namespace-wide": "false",
+		},
+	})
+	assert.Nil(t, err)
+
+	type metadata struct {
+		Labels      map[string]string `yaml:"labels"`
+		Annotations map[string]string `yaml:"annotations"`
+	}
+	actualSS := struct {
+		Metadata metadata `yaml:"metadata"`
+		Spec     struct {
+			Template struct {
+				Metadata metadata `yaml:"metadata"`
+			} `yaml:"template"`
+		} `yaml:"spec"`
+	}{}
+
+	err = yaml.Unmarshal(sealedSecretRaw, &actualSS)
+	assert.Nil(t, err)
+
+	assert.Equal(t, map[string]string{"app.kubernetes.io/managed-by": "terraform"}, actualSS.Metadata.Labels)
+	assert.Equal(t, map[string]string{
+		"argocd.argoproj.io/sync-wave":             "-1",
+		"sealedsecrets.bitnami.com/namespace-wide": "true",
+	}, actualSS.Metadata.Annotations)
+	assert.Equal(t, map[string]string{"app": "template_aa"}, actualSS.Spec.Template.Metadata.Labels)
+	assert.NotContains(t, actualSS.Spec.Template.Metadata.Annotations, "argocd.argoproj.io/sync-wave")
+}
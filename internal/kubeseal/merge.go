@@ -0,0 +1,316 @@
+package kubeseal
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	yamlv3 "gopkg.in/yaml.v3"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// ParseSealedSecret decodes a SealedSecret manifest given as YAML or JSON.
+func ParseSealedSecret(manifest []byte) (*ssv1alpha1.SealedSecret, error) {
+	obj, gvk, err := scheme.Codecs.UniversalDeserializer().Decode(manifest, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode sealed secret manifest: %w", err)
+	}
+	sealedSecret, ok := obj.(*ssv1alpha1.SealedSecret)
+	if !ok {
+		return nil, fmt.Errorf("expected a SealedSecret manifest, got %s", gvk.Kind)
+	}
+	return sealedSecret, nil
+}
+
+// MergeInto seals the keys of secret and merges them into the encryptedData of the SealedSecret
+// manifest, see WithMergeBase.
+func MergeInto(manifest []byte, secret v1.Secret, pk *rsa.PublicKey) ([]byte, error) {
+	return NewSealer(StaticKey(pk), WithMergeBase(manifest)).Seal(context.Background(), secret)
+}
+
+// spliceEncryptedData sets the keys of encryptedData in the spec of a SealedSecret manifest. Only
+// the encryptedData of the manifest is rewritten, every other byte, comments included, is kept.
+// Existing keys keep their position, new keys are appended in sorted order.
+func spliceEncryptedData(manifest []byte, encryptedData map[string]string) ([]byte, error) {
+	if trimmed := bytes.TrimSpace(manifest); len(trimmed) > 0 && trimmed[0] == '{' {
+		return spliceJSON(manifest, encryptedData)
+	}
+	return spliceYAML(manifest, encryptedData)
+}
+
+// spliceYAML replaces the lines of the encryptedData block, or appends one to the spec, with the
+// merged mapping rendered at the same indentation.
+func spliceYAML(manifest []byte, encryptedData map[string]string) ([]byte, error) {
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(manifest, &doc); err != nil {
+		return nil, fmt.Errorf("unable to decode sealed secret manifest: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yamlv3.MappingNode {
+		return nil, fmt.Errorf("sealed secret has no spec")
+	}
+	specKey, spec := yamlMappingValue(doc.Content[0], "spec")
+	if spec == nil || spec.Kind != yamlv3.MappingNode {
+		return nil, fmt.Errorf("sealed secret has no spec")
+	}
+	if doc.Content[0].Style&yamlv3.FlowStyle != 0 || spec.Style&yamlv3.FlowStyle != 0 {
+		return nil, fmt.Errorf("unable to merge into a sealed secret spec in flow style")
+	}
+
+	lines := strings.SplitAfter(string(manifest), "\n")
+	key, value := yamlMappingValue(spec, "encryptedData")
+	var start, end, column int
+	if key != nil {
+		start, column = key.Line-1, key.Column-1
+		end = yamlBlockEnd(lines, start, column)
+		// comments around the block stay where they are
+		key.HeadComment, key.FootComment = "", ""
+	} else {
+		start = yamlBlockEnd(lines, specKey.Line-1, specKey.Column-1)
+		end = start
+		column = spec.Content[0].Column - 1
+		key = &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: "encryptedData"}
+	}
+	if value == nil || value.Kind != yamlv3.MappingNode {
+		value = &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+	}
+	if len(value.Content) == 0 {
+		value.Style = 0
+	}
+	setYAMLMappingValues(value, encryptedData)
+
+	indent := spec.Content[0].Column - specKey.Column
+	var b bytes.Buffer
+	enc := yamlv3.NewEncoder(&b)
+	enc.SetIndent(indent)
+	if err := enc.Encode(&yamlv3.Node{Kind: yamlv3.MappingNode, Content: []*yamlv3.Node{key, value}}); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	if start > 0 && !strings.HasSuffix(lines[start-1], "\n") {
+		lines[start-1] += "\n"
+	}
+	var out strings.Builder
+	out.WriteString(strings.Join(lines[:start], ""))
+	prefix := strings.Repeat(" ", column)
+	for _, line := range strings.SplitAfter(b.String(), "\n") {
+		if line != "" {
+			out.WriteString(prefix + line)
+		}
+	}
+	out.WriteString(strings.Join(lines[end:], ""))
+	return []byte(out.String()), nil
+}
+
+// yamlMappingValue returns the key and value nodes of key in a mapping node, or nils.
+func yamlMappingValue(mapping *yamlv3.Node, key string) (*yamlv3.Node, *yamlv3.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// yamlBlockEnd returns the index of the line after the block of the key on line start, made of the
+// following lines indented deeper than column. Trailing blank lines are not part of the block.
+func yamlBlockEnd(lines []string, start, column int) int {
+	end := start + 1
+	for i := start + 1; i < len(lines); i++ {
+		trimmed := strings.TrimLeft(lines[i], " ")
+		if strings.TrimSpace(trimmed) == "" {
+			continue
+		}
+		if len(lines[i])-len(trimmed) <= column {
+			break
+		}
+		end = i + 1
+	}
+	return end
+}
+
+func setYAMLMappingValues(mapping *yamlv3.Node, values map[string]string) {
+	added := make([]string, 0, len(values))
+	for k := range values {
+		if _, v := yamlMappingValue(mapping, k); v != nil {
+			v.Kind, v.Tag, v.Value, v.Content = yamlv3.ScalarNode, "!!str", values[k], nil
+			continue
+		}
+		added = append(added, k)
+	}
+	sort.Strings(added)
+	for _, k := range added {
+		mapping.Content = append(mapping.Content,
+			&yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: k},
+			&yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: values[k]},
+		)
+	}
+}
+
+// spliceJSON replaces the encryptedData object, or adds one to the spec, with the merged object
+// indented like the rest of the document.
+func spliceJSON(manifest []byte, encryptedData map[string]string) ([]byte, error) {
+	specStart, _, found, err := jsonMember(manifest, 0, "spec")
+	if err != nil {
+		return nil, err
+	}
+	if !found || manifest[specStart] != '{' {
+		return nil, fmt.Errorf("sealed secret has no spec")
+	}
+	start, end, found, err := jsonMember(manifest, specStart, "encryptedData")
+	if err != nil {
+		return nil, err
+	}
+
+	multiline := bytes.Contains(bytes.TrimSpace(manifest), []byte("\n"))
+	specIndent := lineIndent(manifest, specStart)
+	var keys []string
+	values := map[string]string{}
+	var replacement []byte
+	if found {
+		if keys, err = jsonObjectKeys(manifest[start:end], values); err != nil {
+			return nil, err
+		}
+		replacement = renderJSONObject(keys, values, encryptedData, lineIndent(manifest, start), specIndent, multiline)
+	} else {
+		// start is the closing brace of the spec, end the end of its last member
+		memberIndent := specIndent + "  "
+		separator := ","
+		if end == specStart+1 {
+			separator = ""
+		} else {
+			memberIndent = lineIndent(manifest, end-1)
+		}
+		replacement = []byte(separator)
+		if multiline {
+			replacement = append(replacement, "\n"+memberIndent...)
+		}
+		replacement = append(replacement, `"encryptedData":`...)
+		if multiline {
+			replacement = append(replacement, ' ')
+		}
+		replacement = append(replacement, renderJSONObject(nil, values, encryptedData, memberIndent, specIndent, multiline)...)
+		start = end
+	}
+
+	out := make([]byte, 0, len(manifest)+len(replacement))
+	out = append(out, manifest[:start]...)
+	out = append(out, replacement...)
+	out = append(out, manifest[end:]...)
+	return out, nil
+}
+
+// jsonMember finds the member key of the object starting at offset from. If found, start and end
+// delimit its value; otherwise start is the offset of the closing brace and end the end of the last
+// member, or of the opening brace.
+func jsonMember(document []byte, from int, key string) (start, end int, found bool, err error) {
+	dec := json.NewDecoder(bytes.NewReader(document[from:]))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return 0, 0, false, fmt.Errorf("unable to decode sealed secret manifest: expected an object")
+	}
+	end = from + int(dec.InputOffset())
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return 0, 0, false, fmt.Errorf("unable to decode sealed secret manifest: %w", err)
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return 0, 0, false, fmt.Errorf("unable to decode sealed secret manifest: %w", err)
+		}
+		end = from + int(dec.InputOffset())
+		if t == key {
+			return end - len(raw), end, true, nil
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return 0, 0, false, fmt.Errorf("unable to decode sealed secret manifest: %w", err)
+	}
+	return from + int(dec.InputOffset()) - 1, end, false, nil
+}
+
+// jsonObjectKeys returns the keys of a JSON object of strings in order, and stores its values.
+func jsonObjectKeys(object []byte, values map[string]string) ([]string, error) {
+	if bytes.Equal(bytes.TrimSpace(object), []byte("null")) {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(object))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil, fmt.Errorf("encryptedData of the sealed secret is not an object")
+	}
+	var keys []string
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode sealed secret manifest: %w", err)
+		}
+		var v string
+		if err := dec.Decode(&v); err != nil {
+			return nil, fmt.Errorf("encryptedData of the sealed secret is not a map of strings: %w", err)
+		}
+		k := t.(string)
+		keys = append(keys, k)
+		values[k] = v
+	}
+	return keys, nil
+}
+
+// renderJSONObject renders the keys of the existing object, then the keys added by merged in
+// sorted order. Members are indented by the difference between indent and parentIndent.
+func renderJSONObject(keys []string, values, merged map[string]string, indent, parentIndent string, multiline bool) []byte {
+	var added []string
+	for k, v := range merged {
+		if _, ok := values[k]; !ok {
+			added = append(added, k)
+		}
+		values[k] = v
+	}
+	sort.Strings(added)
+	keys = append(keys, added...)
+	if len(keys) == 0 {
+		return []byte("{}")
+	}
+
+	unit := strings.TrimPrefix(indent, parentIndent)
+	if unit == "" {
+		unit = "  "
+	}
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if multiline {
+			b.WriteString("\n" + indent + unit)
+		}
+		key, _ := json.Marshal(k)
+		value, _ := json.Marshal(values[k])
+		b.Write(key)
+		b.WriteByte(':')
+		if multiline {
+			b.WriteByte(' ')
+		}
+		b.Write(value)
+	}
+	if multiline {
+		b.WriteString("\n" + indent)
+	}
+	b.WriteByte('}')
+	return b.Bytes()
+}
+
+// lineIndent returns the leading whitespace of the line holding offset.
+func lineIndent(document []byte, offset int) string {
+	lineStart := bytes.LastIndexByte(document[:offset], '\n') + 1
+	line := document[lineStart:offset]
+	return string(line[:len(line)-len(bytes.TrimLeft(line, " \t"))])
+}
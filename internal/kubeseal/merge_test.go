@@ -0,0 +1,268 @@
+package kubeseal
+
+import (
+	"testing"
+
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/k8s"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+func TestMergeInto(t *testing.T) {
+	pk, err := ParsePublicKey([]byte(pem))
+	assert.Nil(t, err)
+
+	base, err := k8s.CreateSecret(&k8s.SecretManifest{
+		Name:       "name_aa",
+		Namespace:  "ns_aa",
+		Type:       "Opaque",
+		StringData: map[string]string{"keyAA": "valueAA", "keyBB": "valueBB"},
+	})
+	assert.Nil(t, err)
+	manifest, err := SealSecret(base, pk)
+	assert.Nil(t, err)
+
+	type sealedSecret struct {
+		Spec struct {
+			EncryptedData map[string]string `yaml:"encryptedData"`
+		} `yaml:"spec"`
+	}
+	var before sealedSecret
+	assert.Nil(t, yaml.Unmarshal(manifest, &before))
+
+	tests := []struct {
+		Name        string
+		Input       k8s.SecretManifest
+		ExpectedErr string
+	}{
+		{
+			Name: "happy day",
+			Input: k8s.SecretManifest{
+				Name:       "name_aa",
+				Namespace:  "ns_aa",
+				Type:       "Opaque",
+				StringData: map[string]string{"keyBB": "valueBB2", "keyCC": "valueCC"},
+			},
+		},
+		{
+			Name: "name mismatch",
+			Input: k8s.SecretManifest{
+				Name:       "name_bb",
+				Namespace:  "ns_aa",
+				Type:       "Opaque",
+				StringData: map[string]string{"keyCC": "valueCC"},
+			},
+			ExpectedErr: `secret name "name_bb" does not match sealed secret name "name_aa"`,
+		},
+		{
+			Name: "namespace mismatch",
+			Input: k8s.SecretManifest{
+				Name:       "name_aa",
+				Namespace:  "ns_bb",
+				Type:       "Opaque",
+				StringData: map[string]string{"keyCC": "valueCC"},
+			},
+			ExpectedErr: `secret namespace "ns_bb" does not match sealed secret namespace "ns_aa"`,
+		},
+		{
+			Name: "scope mismatch",
+			Input: k8s.SecretManifest{
+				Name:        "name_aa",
+				Namespace:   "ns_aa",
+				Type:        "Opaque",
+				StringData:  map[string]string{"keyCC": "valueCC"},
+				Annotations: map[string]string{"sealedsecrets.bitnami.com/cluster-wide": "true"},
+			},
+			ExpectedErr: "secret scope cluster-wide does not match sealed secret scope strict",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			secret, err := k8s.CreateSecret(&tc.Input)
+			assert.Nil(t, err)
+
+			merged, err := MergeInto(manifest, secret, pk)
+			if tc.ExpectedErr != "" {
+				assert.EqualError(t, err, tc.ExpectedErr)
+				return
+			}
+			assert.Nil(t, err)
+
+			var after sealedSecret
+			assert.Nil(t, yaml.Unmarshal(merged, &after))
+			assert.Equal(t, before.Spec.EncryptedData["keyAA"], after.Spec.EncryptedData["keyAA"])
+			assert.NotEqual(t, before.Spec.EncryptedData["keyBB"], after.Spec.EncryptedData["keyBB"])
+			assert.Contains(t, after.Spec.EncryptedData, "keyCC")
+		})
+	}
+}
+
+func TestParseSealedSecretRejectsOtherKinds(t *testing.T) {
+	_, err := ParseSealedSecret([]byte("apiVersion: v1\nkind: Secret\nmetadata:\n  name: aa\n"))
+	assert.EqualError(t, err, "expected a SealedSecret manifest, got Secret")
+}
+
+func TestSpliceEncryptedData(t *testing.T) {
+	encryptedData := map[string]string{"keyBB": "cipherBB2", "keyCC": "cipherCC"}
+	tests := []struct {
+		Name        string
+		Manifest    string
+		Expected    string
+		ExpectedErr string
+	}{
+		{
+			Name: "yaml keeps comments and key order",
+			Manifest: `# sealed with kubeseal
+kind: SealedSecret
+apiVersion: bitnami.com/v1alpha1
+metadata:
+  name: name-aa # the name
+  namespace: ns-aa
+spec:
+  # encrypted keys
+  encryptedData:
+    keyZZ: cipherZZ # first
+    keyBB: cipherBB
+  template:
+    type: Opaque
+`,
+			Expected: `# sealed with kubeseal
+kind: SealedSecret
+apiVersion: bitnami.com/v1alpha1
+metadata:
+  name: name-aa # the name
+  namespace: ns-aa
+spec:
+  # encrypted keys
+  encryptedData:
+    keyZZ: cipherZZ # first
+    keyBB: cipherBB2
+    keyCC: cipherCC
+  template:
+    type: Opaque
+`,
+		},
+		{
+			Name: "yaml with four spaces indentation and encryptedData last",
+			Manifest: `kind: SealedSecret
+spec:
+    template:
+        type: Opaque
+    encryptedData:
+        keyBB: cipherBB
+
+# end
+`,
+			Expected: `kind: SealedSecret
+spec:
+    template:
+        type: Opaque
+    encryptedData:
+        keyBB: cipherBB2
+        keyCC: cipherCC
+
+# end
+`,
+		},
+		{
+			Name: "yaml without encryptedData",
+			Manifest: `kind: SealedSecret
+spec:
+  template:
+    type: Opaque
+status: {}`,
+			Expected: `kind: SealedSecret
+spec:
+  template:
+    type: Opaque
+  encryptedData:
+    keyBB: cipherBB2
+    keyCC: cipherCC
+status: {}`,
+		},
+		{
+			Name: "yaml with empty encryptedData",
+			Manifest: `spec:
+  encryptedData: {}
+`,
+			Expected: `spec:
+  encryptedData:
+    keyBB: cipherBB2
+    keyCC: cipherCC
+`,
+		},
+		{
+			Name: "json keeps its format",
+			Manifest: `{
+    "kind": "SealedSecret",
+    "spec": {
+        "encryptedData": {
+            "keyZZ": "cipherZZ",
+            "keyBB": "cipherBB"
+        },
+        "template": {"type": "Opaque"}
+    }
+}
+`,
+			Expected: `{
+    "kind": "SealedSecret",
+    "spec": {
+        "encryptedData": {
+            "keyZZ": "cipherZZ",
+            "keyBB": "cipherBB2",
+            "keyCC": "cipherCC"
+        },
+        "template": {"type": "Opaque"}
+    }
+}
+`,
+		},
+		{
+			Name:     "compact json",
+			Manifest: `{"kind":"SealedSecret","spec":{"encryptedData":{"keyBB":"cipherBB"},"template":{}}}`,
+			Expected: `{"kind":"SealedSecret","spec":{"encryptedData":{"keyBB":"cipherBB2","keyCC":"cipherCC"},"template":{}}}`,
+		},
+		{
+			Name: "json without encryptedData",
+			Manifest: `{
+  "kind": "SealedSecret",
+  "spec": {
+    "template": {}
+  }
+}`,
+			Expected: `{
+  "kind": "SealedSecret",
+  "spec": {
+    "template": {},
+    "encryptedData": {
+      "keyBB": "cipherBB2",
+      "keyCC": "cipherCC"
+    }
+  }
+}`,
+		},
+		{
+			Name:        "no spec",
+			Manifest:    "kind: SealedSecret\n",
+			ExpectedErr: "sealed secret has no spec",
+		},
+		{
+			Name:        "json encryptedData of another type",
+			Manifest:    `{"spec":{"encryptedData":{"keyAA":1}}}`,
+			ExpectedErr: "encryptedData of the sealed secret is not a map of strings: json: cannot unmarshal number into Go value of type string",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			spliced, err := spliceEncryptedData([]byte(tc.Manifest), encryptedData)
+			if tc.ExpectedErr != "" {
+				assert.EqualError(t, err, tc.ExpectedErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.Expected, string(spliced))
+		})
+	}
+}
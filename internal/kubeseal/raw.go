@@ -0,0 +1,22 @@
+package kubeseal
+
+import (
+	"context"
+	"crypto/rsa"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+)
+
+// SealValue encrypts a single value like kubeseal --raw, returning the base64 ciphertext to use in
+// the encryptedData of a SealedSecret. The name is required by the strict scope and the namespace
+// by the strict and namespace-wide scopes, as they are part of the encryption label.
+func SealValue(pk *rsa.PublicKey, name, namespace string, scope ssv1alpha1.SealingScope, value []byte) (string, error) {
+	return NewSealer(StaticKey(pk)).SealValue(context.Background(), name, namespace, scope, value)
+}
+
+// Fingerprint returns the SHA-256 fingerprint of the public key (SHA256:<base64>), the one the
+// controller uses to identify its sealing keys.
+func Fingerprint(pk *rsa.PublicKey) (string, error) {
+	return crypto.PublicKeyFingerprint(pk)
+}
@@ -0,0 +1,70 @@
+package kubeseal
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSealValue(t *testing.T) {
+	key, _, err := crypto.GeneratePrivateKeyAndCert(2048, time.Hour, "test")
+	assert.Nil(t, err)
+	fingerprint, err := Fingerprint(&key.PublicKey)
+	assert.Nil(t, err)
+	keys := map[string]*rsa.PrivateKey{fingerprint: key}
+
+	tests := []struct {
+		Name        string
+		SecretName  string
+		Namespace   string
+		Scope       ssv1alpha1.SealingScope
+		ExpectedErr string
+	}{
+		{Name: "strict", SecretName: "name_aa", Namespace: "ns_aa", Scope: ssv1alpha1.StrictScope},
+		{Name: "namespace-wide", Namespace: "ns_aa", Scope: ssv1alpha1.NamespaceWideScope},
+		{Name: "cluster-wide", Scope: ssv1alpha1.ClusterWideScope},
+		{Name: "strict without name", Namespace: "ns_aa", Scope: ssv1alpha1.StrictScope, ExpectedErr: "name is required for the strict scope"},
+		{Name: "namespace-wide without namespace", Scope: ssv1alpha1.NamespaceWideScope, ExpectedErr: "namespace is required for the namespace-wide scope"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			sealed, err := SealValue(&key.PublicKey, tc.SecretName, tc.Namespace, tc.Scope, []byte("valueAA"))
+			if tc.ExpectedErr != "" {
+				assert.EqualError(t, err, tc.ExpectedErr)
+				return
+			}
+			assert.Nil(t, err)
+
+			ciphertext, err := base64.StdEncoding.DecodeString(sealed)
+			assert.Nil(t, err)
+			label := ssv1alpha1.EncryptionLabel(tc.Namespace, tc.SecretName, tc.Scope)
+			plaintext, err := crypto.HybridDecrypt(rand.Reader, keys, ciphertext, label)
+			assert.Nil(t, err)
+			assert.Equal(t, "valueAA", string(plaintext))
+
+			// The label binds the value to its scope, it cannot be unsealed as another secret.
+			_, err = crypto.HybridDecrypt(rand.Reader, keys, ciphertext, ssv1alpha1.EncryptionLabel("ns_bb", "name_bb", ssv1alpha1.StrictScope))
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	pk, err := ParsePublicKey([]byte(pem))
+	assert.Nil(t, err)
+	fingerprint, err := Fingerprint(pk)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(fingerprint, "SHA256:"), fingerprint)
+
+	again, err := Fingerprint(pk)
+	assert.Nil(t, err)
+	assert.Equal(t, fingerprint, again)
+}
@@ -0,0 +1,297 @@
+package kubeseal
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+// Output formats of a Sealer
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+// Sealer seals secrets into SealedSecret manifests with the public key of a controller. The zero
+// value is not usable, create one with NewSealer.
+type Sealer struct {
+	key       PKResolverFunc
+	format    string
+	rand      io.Reader
+	meta      ObjectMeta
+	mergeBase []byte
+}
+
+// SealerOption configures a Sealer.
+type SealerOption func(*Sealer)
+
+// WithFormat sets the output format, FormatYAML by default.
+func WithFormat(format string) SealerOption {
+	return func(s *Sealer) {
+		s.format = format
+	}
+}
+
+// WithRand sets the random source of the session keys, crypto/rand by default. A deterministic
+// source makes the output reproducible, which is only ever wanted in tests.
+func WithRand(r io.Reader) SealerOption {
+	return func(s *Sealer) {
+		s.rand = r
+	}
+}
+
+// WithObjectMeta sets labels and annotations on the SealedSecret object itself. Scope annotations
+// derived from the secret always take precedence.
+func WithObjectMeta(meta ObjectMeta) SealerOption {
+	return func(s *Sealer) {
+		s.meta = meta
+	}
+}
+
+// WithKeyCache resolves the public key once for the lifetime of the Sealer. Failed resolutions are
+// not cached.
+func WithKeyCache() SealerOption {
+	return func(s *Sealer) {
+		resolve := s.key
+		var mu sync.Mutex
+		var cached *rsa.PublicKey
+		s.key = func(ctx context.Context) (*rsa.PublicKey, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if cached != nil {
+				return cached, nil
+			}
+			pk, err := resolve(ctx)
+			if err != nil {
+				return nil, err
+			}
+			cached = pk
+			return pk, nil
+		}
+	}
+}
+
+// WithMergeBase merges the sealed keys into the encryptedData of the SealedSecret manifest instead
+// of producing a new one, like kubeseal --merge-into. Keys not present in the secret keep their
+// ciphertext untouched; keys present in both are replaced. The secret must match the name,
+// namespace and scope of the manifest, otherwise the merged keys could never be decrypted by the
+// controller. Only the encryptedData of the manifest is rewritten: its format, key order, comments
+// and metadata are kept, WithFormat and WithObjectMeta do not apply.
+func WithMergeBase(manifest []byte) SealerOption {
+	return func(s *Sealer) {
+		s.mergeBase = manifest
+	}
+}
+
+// NewSealer returns a Sealer sealing with the key resolved by key. Options are applied in order.
+func NewSealer(key PKResolverFunc, opts ...SealerOption) *Sealer {
+	s := &Sealer{
+		key:    key,
+		format: FormatYAML,
+		rand:   rand.Reader,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// StaticKey resolves to pk.
+func StaticKey(pk *rsa.PublicKey) PKResolverFunc {
+	return func(context.Context) (*rsa.PublicKey, error) {
+		return pk, nil
+	}
+}
+
+// Seal seals the secret into a SealedSecret manifest, or merges it into the merge base.
+func (s *Sealer) Seal(ctx context.Context, secret v1.Secret) ([]byte, error) {
+	if s.format != FormatYAML && s.format != FormatJSON {
+		return nil, fmt.Errorf("format must be one of %s or %s, given %s", FormatYAML, FormatJSON, s.format)
+	}
+	pk, err := s.key(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve the public key: %w", err)
+	}
+	if s.mergeBase != nil {
+		return s.merge(pk, secret)
+	}
+
+	if err := ValidateSealedSize(pk, &secret); err != nil {
+		return nil, err
+	}
+
+	// Strip read-only server-side ObjectMeta (if present)
+	secret.SetSelfLink("")
+	secret.SetUID("")
+	secret.SetResourceVersion("")
+	secret.Generation = 0
+	secret.SetCreationTimestamp(metav1.Time{})
+	secret.SetDeletionTimestamp(nil)
+	secret.DeletionGracePeriodSeconds = nil
+	secret.ManagedFields = nil
+
+	sealedSecret, err := s.newSealedSecret(pk, &secret)
+	if err != nil {
+		return nil, fmt.Errorf("unable to seal secret: %w", err)
+	}
+	applyObjectMeta(sealedSecret, s.meta, ssv1alpha1.SecretScope(&secret))
+
+	mediaType := runtime.ContentTypeYAML
+	if s.format == FormatJSON {
+		mediaType = runtime.ContentTypeJSON
+	}
+	prettyEnc, err := prettyEncoder(scheme.Codecs, mediaType, ssv1alpha1.SchemeGroupVersion)
+	if err != nil {
+		return nil, err
+	}
+	encodedSealedSecret, err := runtime.Encode(prettyEnc, sealedSecret)
+	if err != nil {
+		return nil, err
+	}
+	if secret.Immutable != nil && *secret.Immutable {
+		return s.setTemplateImmutable(encodedSealedSecret)
+	}
+	return encodedSealedSecret, nil
+}
+
+// SealValue encrypts a single value like kubeseal --raw, see the SealValue function.
+func (s *Sealer) SealValue(ctx context.Context, name, namespace string, scope ssv1alpha1.SealingScope, value []byte) (string, error) {
+	if scope < ssv1alpha1.ClusterWideScope && namespace == "" {
+		return "", fmt.Errorf("namespace is required for the %s scope", scope.String())
+	}
+	if scope < ssv1alpha1.NamespaceWideScope && name == "" {
+		return "", fmt.Errorf("name is required for the %s scope", scope.String())
+	}
+	pk, err := s.key(ctx)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve the public key: %w", err)
+	}
+
+	ciphertext, err := crypto.HybridEncrypt(s.rand, pk, value, ssv1alpha1.EncryptionLabel(namespace, name, scope))
+	if err != nil {
+		return "", fmt.Errorf("unable to seal value: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// newSealedSecret is ssv1alpha1.NewSealedSecret with the random source of the Sealer. Keys are
+// encrypted in sorted order so that a deterministic source gives a reproducible output.
+func (s *Sealer) newSealedSecret(pk *rsa.PublicKey, secret *v1.Secret) (*ssv1alpha1.SealedSecret, error) {
+	scope := ssv1alpha1.SecretScope(secret)
+	if scope != ssv1alpha1.ClusterWideScope && secret.GetNamespace() == "" {
+		return nil, fmt.Errorf("secret must declare a namespace")
+	}
+
+	sealedSecret := &ssv1alpha1.SealedSecret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secret.GetName(),
+			Namespace: secret.GetNamespace(),
+		},
+		Spec: ssv1alpha1.SealedSecretSpec{
+			Template: ssv1alpha1.SecretTemplateSpec{
+				Type: secret.Type,
+			},
+			EncryptedData: map[string]string{},
+		},
+	}
+	secret.ObjectMeta.DeepCopyInto(&sealedSecret.Spec.Template.ObjectMeta)
+	// a kubectl applied secret carries a copy of its values in an annotation
+	ssv1alpha1.StripLastAppliedAnnotations(sealedSecret.Spec.Template.ObjectMeta.Annotations)
+	sealedSecret.Spec.Template.ObjectMeta.OwnerReferences = nil
+
+	values := make(map[string][]byte, len(secret.Data)+len(secret.StringData))
+	for k, v := range secret.Data {
+		values[k] = v
+	}
+	for k, v := range secret.StringData {
+		values[k] = []byte(v)
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	label := ssv1alpha1.EncryptionLabel(secret.GetNamespace(), secret.GetName(), scope)
+	for _, k := range keys {
+		ciphertext, err := crypto.HybridEncrypt(s.rand, pk, values[k], label)
+		if err != nil {
+			return nil, err
+		}
+		sealedSecret.Spec.EncryptedData[k] = base64.StdEncoding.EncodeToString(ciphertext)
+	}
+
+	sealedSecret.Annotations = ssv1alpha1.UpdateScopeAnnotations(sealedSecret.Annotations, scope)
+	return sealedSecret, nil
+}
+
+func (s *Sealer) merge(pk *rsa.PublicKey, secret v1.Secret) ([]byte, error) {
+	base, err := ParseSealedSecret(s.mergeBase)
+	if err != nil {
+		return nil, err
+	}
+	if base.Name != secret.Name {
+		return nil, fmt.Errorf("secret name %q does not match sealed secret name %q", secret.Name, base.Name)
+	}
+	if base.Namespace != secret.Namespace {
+		return nil, fmt.Errorf("secret namespace %q does not match sealed secret namespace %q", secret.Namespace, base.Namespace)
+	}
+	baseScope, secretScope := base.Scope(), ssv1alpha1.SecretScope(&secret)
+	if baseScope != secretScope {
+		return nil, fmt.Errorf("secret scope %s does not match sealed secret scope %s", secretScope.String(), baseScope.String())
+	}
+	if err := ValidateSealedSize(pk, &secret); err != nil {
+		return nil, err
+	}
+
+	sealed, err := s.newSealedSecret(pk, &secret)
+	if err != nil {
+		return nil, fmt.Errorf("unable to seal secret: %w", err)
+	}
+
+	return spliceEncryptedData(s.mergeBase, sealed.Spec.EncryptedData)
+}
+
+// setTemplateImmutable marks the template of an encoded SealedSecret as immutable. The vendored
+// SecretTemplateSpec predates the immutable field, so it is added to the encoded object instead.
+func (s *Sealer) setTemplateImmutable(encodedSealedSecret []byte) ([]byte, error) {
+	var obj map[string]interface{}
+	if err := yaml.Unmarshal(encodedSealedSecret, &obj); err != nil {
+		return nil, err
+	}
+	spec, ok := obj["spec"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("sealed secret has no spec")
+	}
+	template, ok := spec["template"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("sealed secret has no template")
+	}
+	template["immutable"] = true
+	return s.marshal(obj)
+}
+
+func (s *Sealer) marshal(obj map[string]interface{}) ([]byte, error) {
+	if s.format == FormatJSON {
+		b, err := json.MarshalIndent(obj, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(b, '\n'), nil
+	}
+	return yaml.Marshal(obj)
+}
@@ -0,0 +1,149 @@
+package kubeseal
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"flag"
+	mathrand "math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+var update = flag.Bool("update", false, "update the golden files of testdata")
+
+func testSecret() v1.Secret {
+	immutable := true
+	return v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "name-aa", Namespace: "ns-aa", Labels: map[string]string{"labelAA": "valueAA"}},
+		Type:       v1.SecretTypeOpaque,
+		Data:       map[string][]byte{"keyAA": []byte("valueAA"), "keyBB": []byte("valueBB")},
+		StringData: map[string]string{"keyCC": "valueCC"},
+		Immutable:  &immutable,
+	}
+}
+
+func testKey(t *testing.T) *rsa.PublicKey {
+	pk, err := ParsePublicKey([]byte(pem))
+	assert.Nil(t, err)
+	return pk
+}
+
+func TestSealerGolden(t *testing.T) {
+	for _, format := range []string{FormatYAML, FormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			seal := func() []byte {
+				sealer := NewSealer(StaticKey(testKey(t)),
+					WithFormat(format),
+					WithRand(mathrand.New(mathrand.NewSource(1))),
+					WithObjectMeta(ObjectMeta{Annotations: map[string]string{"annotationAA": "valueAA"}}),
+				)
+				sealed, err := sealer.Seal(context.Background(), testSecret())
+				assert.Nil(t, err)
+				return sealed
+			}
+			sealed := seal()
+			assert.Equal(t, string(sealed), string(seal()))
+
+			golden := filepath.Join("testdata", "sealed_secret.golden."+format)
+			if *update {
+				assert.Nil(t, os.WriteFile(golden, sealed, 0o644))
+			}
+			expected, err := os.ReadFile(golden)
+			assert.Nil(t, err)
+			assert.Equal(t, string(expected), string(sealed))
+		})
+	}
+}
+
+func TestSealerUnseal(t *testing.T) {
+	key, _, err := crypto.GeneratePrivateKeyAndCert(2048, time.Hour, "test")
+	assert.Nil(t, err)
+	fingerprint, err := Fingerprint(&key.PublicKey)
+	assert.Nil(t, err)
+
+	for _, scope := range []ssv1alpha1.SealingScope{ssv1alpha1.StrictScope, ssv1alpha1.NamespaceWideScope, ssv1alpha1.ClusterWideScope} {
+		t.Run(scope.String(), func(t *testing.T) {
+			secret := testSecret()
+			secret.Annotations = ssv1alpha1.UpdateScopeAnnotations(nil, scope)
+			sealed, err := NewSealer(StaticKey(&key.PublicKey)).Seal(context.Background(), secret)
+			assert.Nil(t, err)
+			sealedSecret, err := ParseSealedSecret(sealed)
+			assert.Nil(t, err)
+
+			unsealed, err := sealedSecret.Unseal(scheme.Codecs, map[string]*rsa.PrivateKey{fingerprint: key})
+			assert.Nil(t, err)
+			assert.Equal(t, map[string][]byte{
+				"keyAA": []byte("valueAA"),
+				"keyBB": []byte("valueBB"),
+				"keyCC": []byte("valueCC"),
+			}, unsealed.Data)
+		})
+	}
+}
+
+func TestSealerMergeBase(t *testing.T) {
+	sealer := NewSealer(StaticKey(testKey(t)), WithRand(mathrand.New(mathrand.NewSource(1))))
+	secret := testSecret()
+	secret.Immutable = nil
+	base, err := sealer.Seal(context.Background(), secret)
+	assert.Nil(t, err)
+	baseSealedSecret, err := ParseSealedSecret(base)
+	assert.Nil(t, err)
+
+	update := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "name-aa", Namespace: "ns-aa"},
+		StringData: map[string]string{"keyAA": "valueZZ", "keyDD": "valueDD"},
+	}
+	merged, err := NewSealer(StaticKey(testKey(t)), WithMergeBase(base), WithFormat(FormatJSON)).Seal(context.Background(), update)
+	assert.Nil(t, err)
+	// the merge base keeps its format
+	assert.True(t, strings.HasPrefix(string(merged), "apiVersion: bitnami.com/v1alpha1\nkind: SealedSecret\n"))
+
+	mergedSealedSecret, err := ParseSealedSecret(merged)
+	assert.Nil(t, err)
+	assert.Len(t, mergedSealedSecret.Spec.EncryptedData, 4)
+	assert.Equal(t, baseSealedSecret.Spec.EncryptedData["keyBB"], mergedSealedSecret.Spec.EncryptedData["keyBB"])
+	assert.NotEqual(t, baseSealedSecret.Spec.EncryptedData["keyAA"], mergedSealedSecret.Spec.EncryptedData["keyAA"])
+	assert.Equal(t, map[string]string{"labelAA": "valueAA"}, mergedSealedSecret.Spec.Template.Labels)
+
+	// merged keys are held to the size limit of sealing
+	update.StringData = map[string]string{"keyAA": strings.Repeat("a", MaxEncryptedDataSize)}
+	_, err = NewSealer(StaticKey(testKey(t)), WithMergeBase(base)).Seal(context.Background(), update)
+	assert.ErrorContains(t, err, "more than the")
+}
+
+func TestSealerKeyCache(t *testing.T) {
+	calls := 0
+	resolve := func(context.Context) (*rsa.PublicKey, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("controller unavailable")
+		}
+		return testKey(t), nil
+	}
+	sealer := NewSealer(resolve, WithKeyCache())
+
+	_, err := sealer.Seal(context.Background(), testSecret())
+	assert.ErrorContains(t, err, "unable to resolve the public key: controller unavailable")
+	for i := 0; i < 3; i++ {
+		_, err = sealer.SealValue(context.Background(), "name-aa", "ns-aa", ssv1alpha1.StrictScope, []byte("valueAA"))
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestSealerInvalidFormat(t *testing.T) {
+	_, err := NewSealer(StaticKey(testKey(t)), WithFormat("toml")).Seal(context.Background(), testSecret())
+	assert.EqualError(t, err, "format must be one of yaml or json, given toml")
+}
@@ -0,0 +1,42 @@
+package kubeseal
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// MaxEncryptedDataSize is the practical limit of the encryptedData of a SealedSecret: the object
+// must fit the 1.5 MiB default request size limit of etcd. Sealing adds an encrypted session key to
+// every value, so a secret of many small values grows past it well within the 1 MiB limit of the
+// unsealed secret.
+const MaxEncryptedDataSize = 3 * 1024 * 1024 / 2
+
+// EncryptedDataSize returns the size the encryptedData of the secret sealed with pk takes, keys
+// included. Every value is prefixed with the length and RSA ciphertext of its session key, followed
+// by the AES-GCM tag, then base64 encoded.
+func EncryptedDataSize(pk *rsa.PublicKey, secret *v1.Secret) int {
+	size := 0
+	add := func(k string, n int) {
+		size += len(k) + base64.StdEncoding.EncodedLen(2+pk.Size()+n+16)
+	}
+	for k, v := range secret.Data {
+		if _, ok := secret.StringData[k]; !ok {
+			add(k, len(v))
+		}
+	}
+	for k, v := range secret.StringData {
+		add(k, len(v))
+	}
+	return size
+}
+
+// ValidateSealedSize checks that the secret sealed with pk fits MaxEncryptedDataSize.
+func ValidateSealedSize(pk *rsa.PublicKey, secret *v1.Secret) error {
+	if size := EncryptedDataSize(pk, secret); size > MaxEncryptedDataSize {
+		return fmt.Errorf("sealed secret takes %d bytes, more than the %d bytes the controller can practically store", size, MaxEncryptedDataSize)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package kubeseal
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestEncryptedDataSize(t *testing.T) {
+	key, _, err := crypto.GeneratePrivateKeyAndCert(2048, time.Hour, "test")
+	assert.Nil(t, err)
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "name-aa", Namespace: "ns-aa"},
+		Data:       map[string][]byte{"keyAA": []byte("valueAA"), "keyBB": []byte("valueBB")},
+		StringData: map[string]string{"keyBB": "value", "keyCC": ""},
+	}
+	sealed, err := ssv1alpha1.NewSealedSecret(scheme.Codecs, &key.PublicKey, secret)
+	assert.Nil(t, err)
+
+	expected := 0
+	for k, v := range sealed.Spec.EncryptedData {
+		expected += len(k) + len(v)
+	}
+	assert.Equal(t, expected, EncryptedDataSize(&key.PublicKey, secret))
+	assert.Nil(t, ValidateSealedSize(&key.PublicKey, secret))
+
+	// a session key per value
+	many := &v1.Secret{Data: map[string][]byte{}}
+	for i := 0; i < 5000; i++ {
+		many.Data[fmt.Sprintf("key%d", i)] = []byte("a")
+	}
+	assert.ErrorContains(t, ValidateSealedSize(&key.PublicKey, many), "more than the 1572864 bytes")
+
+	large := &v1.Secret{Data: map[string][]byte{"keyAA": []byte(strings.Repeat("a", v1.MaxSecretSize))}}
+	assert.Nil(t, ValidateSealedSize(&key.PublicKey, large))
+}
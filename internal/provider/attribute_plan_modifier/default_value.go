@@ -4,21 +4,21 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/hashicorp/terraform-plugin-framework/attr"
-	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
-// defaultValueAttributePlanModifier specifies a default value (attr.Value) for an attribute.
+// defaultValueAttributePlanModifier specifies a default value for a string attribute.
 type defaultValueAttributePlanModifier struct {
-	DefaultValue attr.Value
+	DefaultValue types.String
 }
 
 // DefaultValue is an helper to instantiate a defaultValueAttributePlanModifier.
-func DefaultValue(v attr.Value) tfsdk.AttributePlanModifier {
+func DefaultValue(v types.String) planmodifier.String {
 	return &defaultValueAttributePlanModifier{v}
 }
 
-var _ tfsdk.AttributePlanModifier = (*defaultValueAttributePlanModifier)(nil)
+var _ planmodifier.String = (*defaultValueAttributePlanModifier)(nil)
 
 func (apm *defaultValueAttributePlanModifier) Description(ctx context.Context) string {
 	return apm.MarkdownDescription(ctx)
@@ -28,17 +28,17 @@ func (apm *defaultValueAttributePlanModifier) MarkdownDescription(ctx context.Co
 	return fmt.Sprintf("Sets the default value %q (%s) if the attribute is not set", apm.DefaultValue, apm.DefaultValue.Type(ctx))
 }
 
-func (apm *defaultValueAttributePlanModifier) Modify(_ context.Context, req tfsdk.ModifyAttributePlanRequest, res *tfsdk.ModifyAttributePlanResponse) {
+func (apm *defaultValueAttributePlanModifier) PlanModifyString(_ context.Context, req planmodifier.StringRequest, res *planmodifier.StringResponse) {
 	// If the attribute configuration is not null, we are done here
-	if !req.AttributeConfig.IsNull() {
+	if !req.ConfigValue.IsNull() {
 		return
 	}
 
 	// If the attribute plan is "known" and "not null", then a previous plan modifier in the sequence
 	// has already been applied, and we don't want to interfere.
-	if !req.AttributePlan.IsUnknown() && !req.AttributePlan.IsNull() {
+	if !req.PlanValue.IsUnknown() && !req.PlanValue.IsNull() {
 		return
 	}
 
-	res.AttributePlan = apm.DefaultValue
-}
\ No newline at end of file
+	res.PlanValue = apm.DefaultValue
+}
@@ -0,0 +1,56 @@
+package attribute_plan_modifier
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// defaultValueIfConfiguredAttributePlanModifier specifies a default value for a string attribute
+// that only applies when another list attribute or block is configured.
+type defaultValueIfConfiguredAttributePlanModifier struct {
+	Path         path.Path
+	DefaultValue types.String
+}
+
+// DefaultValueIfConfigured is an helper to instantiate a defaultValueIfConfiguredAttributePlanModifier.
+func DefaultValueIfConfigured(p path.Path, v types.String) planmodifier.String {
+	return &defaultValueIfConfiguredAttributePlanModifier{p, v}
+}
+
+var _ planmodifier.String = (*defaultValueIfConfiguredAttributePlanModifier)(nil)
+
+func (apm *defaultValueIfConfiguredAttributePlanModifier) Description(ctx context.Context) string {
+	return apm.MarkdownDescription(ctx)
+}
+
+func (apm *defaultValueIfConfiguredAttributePlanModifier) MarkdownDescription(ctx context.Context) string {
+	return fmt.Sprintf("Sets the default value %q (%s) if the attribute is not set and %s is configured", apm.DefaultValue, apm.DefaultValue.Type(ctx), apm.Path)
+}
+
+func (apm *defaultValueIfConfiguredAttributePlanModifier) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, res *planmodifier.StringResponse) {
+	// If the attribute configuration is not null, we are done here
+	if !req.ConfigValue.IsNull() {
+		return
+	}
+
+	// If the attribute plan is "known" and "not null", then a previous plan modifier in the sequence
+	// has already been applied, and we don't want to interfere.
+	if !req.PlanValue.IsUnknown() && !req.PlanValue.IsNull() {
+		return
+	}
+
+	var other types.List
+	res.Diagnostics.Append(req.Config.GetAttribute(ctx, apm.Path, &other)...)
+	if res.Diagnostics.HasError() {
+		return
+	}
+	if other.IsNull() || (!other.IsUnknown() && len(other.Elements()) == 0) {
+		return
+	}
+
+	res.PlanValue = apm.DefaultValue
+}
@@ -0,0 +1,71 @@
+package attribute_plan_modifier
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// requiresReplaceIfTrueAttributePlanModifier requires replacement when the attribute changes while
+// the bool attribute at Path is true in the prior state.
+type requiresReplaceIfTrueAttributePlanModifier struct {
+	Path path.Path
+}
+
+// RequiresReplaceIfTrueModifier is satisfied by the modifier returned by RequiresReplaceIfTrue, which
+// applies to string, map and list attributes as well as list blocks.
+type RequiresReplaceIfTrueModifier interface {
+	planmodifier.String
+	planmodifier.Map
+	planmodifier.List
+}
+
+// RequiresReplaceIfTrue is an helper to instantiate a requiresReplaceIfTrueAttributePlanModifier.
+func RequiresReplaceIfTrue(p path.Path) RequiresReplaceIfTrueModifier {
+	return &requiresReplaceIfTrueAttributePlanModifier{p}
+}
+
+func (apm *requiresReplaceIfTrueAttributePlanModifier) Description(ctx context.Context) string {
+	return apm.MarkdownDescription(ctx)
+}
+
+func (apm *requiresReplaceIfTrueAttributePlanModifier) MarkdownDescription(ctx context.Context) string {
+	return fmt.Sprintf("Requires replacement if the attribute changes while %s is true", apm.Path)
+}
+
+func (apm *requiresReplaceIfTrueAttributePlanModifier) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, res *planmodifier.StringResponse) {
+	res.RequiresReplace = apm.requiresReplace(ctx, req.State, req.Plan, req.StateValue, req.PlanValue, &res.Diagnostics)
+}
+
+func (apm *requiresReplaceIfTrueAttributePlanModifier) PlanModifyMap(ctx context.Context, req planmodifier.MapRequest, res *planmodifier.MapResponse) {
+	res.RequiresReplace = apm.requiresReplace(ctx, req.State, req.Plan, req.StateValue, req.PlanValue, &res.Diagnostics)
+}
+
+func (apm *requiresReplaceIfTrueAttributePlanModifier) PlanModifyList(ctx context.Context, req planmodifier.ListRequest, res *planmodifier.ListResponse) {
+	res.RequiresReplace = apm.requiresReplace(ctx, req.State, req.Plan, req.StateValue, req.PlanValue, &res.Diagnostics)
+}
+
+func (apm *requiresReplaceIfTrueAttributePlanModifier) requiresReplace(ctx context.Context, state tfsdk.State, plan tfsdk.Plan, stateValue, planValue attr.Value, diags *diag.Diagnostics) bool {
+	// Nothing to replace when creating or deleting the resource
+	if state.Raw.IsNull() || plan.Raw.IsNull() {
+		return false
+	}
+
+	if planValue.Equal(stateValue) {
+		return false
+	}
+
+	var flag types.Bool
+	diags.Append(state.GetAttribute(ctx, apm.Path, &flag)...)
+	if diags.HasError() {
+		return false
+	}
+
+	return flag.ValueBool()
+}
@@ -0,0 +1,283 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/kubeseal"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	providerschema "github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	clusters            = "cluster"
+	cert                = "cert"
+	certFile            = "cert_file"
+	certURL             = "cert_url"
+	fetchFromController = "fetch_from_controller"
+	publicKey           = "public_key"
+)
+
+type clusterModel struct {
+	Name                types.String `tfsdk:"name"`
+	Cert                types.String `tfsdk:"cert"`
+	CertFile            types.String `tfsdk:"cert_file"`
+	CertURL             types.String `tfsdk:"cert_url"`
+	FetchFromController types.Bool   `tfsdk:"fetch_from_controller"`
+	ControllerName      types.String `tfsdk:"controller_name"`
+	ControllerNamespace types.String `tfsdk:"controller_namespace"`
+}
+
+func clusterBlock() providerschema.ListNestedBlock {
+	return providerschema.ListNestedBlock{
+		Description: "A named cluster whose certificate resources can use by setting cluster instead of public_key. " +
+			"Exactly one of cert, cert_file, cert_url and fetch_from_controller must be set",
+		NestedObject: providerschema.NestedBlockObject{
+			Attributes: map[string]providerschema.Attribute{
+				name: providerschema.StringAttribute{
+					Required:    true,
+					Description: "Name resources refer to the cluster by",
+				},
+				cert: providerschema.StringAttribute{
+					Optional:    true,
+					Description: "PEM encoded certificate of the controller",
+				},
+				certFile: providerschema.StringAttribute{
+					Optional:    true,
+					Description: "File holding the PEM encoded certificate of the controller",
+				},
+				certURL: providerschema.StringAttribute{
+					Optional:    true,
+					Description: "URL serving the PEM encoded certificate of the controller, like kubeseal --cert",
+				},
+				fetchFromController: providerschema.BoolAttribute{
+					Optional:    true,
+					Description: "Fetch the certificate from the controller through the kubernetes block",
+				},
+				controllerName: providerschema.StringAttribute{
+					Optional:    true,
+					Description: "Name of the controller service to fetch the certificate from, defaults to controller_name of the provider",
+				},
+				controllerNamespace: providerschema.StringAttribute{
+					Optional:    true,
+					Description: "Namespace of the controller service to fetch the certificate from, defaults to controller_namespace of the provider",
+				},
+			},
+		},
+	}
+}
+
+// loadClusters reads and validates the certificate of every cluster into data.Clusters.
+func loadClusters(ctx context.Context, config []clusterModel, data *providerData) diag.Diagnostics {
+	var diags diag.Diagnostics
+	data.Clusters = make(map[string]string, len(config))
+
+	for i, c := range config {
+		p := path.Root(clusters).AtListIndex(i)
+		if c.Name.IsUnknown() || c.Cert.IsUnknown() || c.CertFile.IsUnknown() || c.CertURL.IsUnknown() ||
+			c.FetchFromController.IsUnknown() || c.ControllerName.IsUnknown() || c.ControllerNamespace.IsUnknown() {
+			diags.AddAttributeError(p, "Unknown cluster configuration",
+				"The cluster block depends on values only known after apply, its certificate cannot be loaded.")
+			continue
+		}
+		clusterName := c.Name.ValueString()
+		if _, ok := data.Clusters[clusterName]; ok {
+			diags.AddAttributeError(p.AtName(name), "Duplicate cluster", fmt.Sprintf("cluster %q is defined more than once", clusterName))
+			continue
+		}
+
+		var sources []string
+		for _, s := range []struct {
+			name string
+			set  bool
+		}{
+			{cert, !c.Cert.IsNull()},
+			{certFile, !c.CertFile.IsNull()},
+			{certURL, !c.CertURL.IsNull()},
+			{fetchFromController, c.FetchFromController.ValueBool()},
+		} {
+			if s.set {
+				sources = append(sources, s.name)
+			}
+		}
+		if len(sources) != 1 {
+			diags.AddAttributeError(p, "Invalid cluster configuration",
+				fmt.Sprintf("cluster %q must set exactly one of %s, %s, %s and %s, got %v", clusterName, cert, certFile, certURL, fetchFromController, sources))
+			continue
+		}
+
+		pem, err := readClusterCert(ctx, c, data)
+		if err != nil {
+			diags.AddAttributeError(p.AtName(sources[0]), "Failed to load cluster certificate", fmt.Sprintf("cluster %q: %s", clusterName, err))
+			continue
+		}
+		pk, err := kubeseal.ParsePublicKey(pem)
+		if err != nil {
+			diags.AddAttributeError(p.AtName(sources[0]), "Invalid cluster certificate", fmt.Sprintf("cluster %q: %s", clusterName, err))
+			continue
+		}
+		fingerprint, err := kubeseal.Fingerprint(pk)
+		if err != nil {
+			diags.AddAttributeError(p.AtName(sources[0]), "Invalid cluster certificate", fmt.Sprintf("cluster %q: %s", clusterName, err))
+			continue
+		}
+
+		tflog.Info(ctx, "Loaded cluster certificate", map[string]interface{}{
+			"cluster":     clusterName,
+			"source":      sources[0],
+			"fingerprint": fingerprint,
+		})
+		data.Clusters[clusterName] = string(pem)
+	}
+
+	return diags
+}
+
+func readClusterCert(ctx context.Context, c clusterModel, data *providerData) ([]byte, error) {
+	switch {
+	case !c.Cert.IsNull():
+		return []byte(c.Cert.ValueString()), nil
+	case !c.CertFile.IsNull():
+		return os.ReadFile(c.CertFile.ValueString())
+	case !c.CertURL.IsNull():
+		return fetchCertURL(ctx, c.CertURL.ValueString())
+	}
+
+	if data.Client == nil {
+		return nil, fmt.Errorf("%s requires the kubernetes block of the provider", fetchFromController)
+	}
+	controller, controllerNs := c.ControllerName.ValueString(), c.ControllerNamespace.ValueString()
+	if c.ControllerName.IsNull() || c.ControllerNamespace.IsNull() {
+		name, namespace, err := data.controller(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if c.ControllerName.IsNull() {
+			controller = name
+		}
+		if c.ControllerNamespace.IsNull() {
+			controllerNs = namespace
+		}
+	}
+	return data.Client.Get(ctx, controller, controllerNs, "/v1/cert.pem")
+}
+
+func fetchCertURL(ctx context.Context, url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// clusterCert returns the certificate of the named cluster of the provider configuration.
+func (d *providerData) clusterCert(clusterName string) (string, error) {
+	if d == nil {
+		return "", fmt.Errorf("the provider is not configured")
+	}
+	pem, ok := d.Clusters[clusterName]
+	if !ok {
+		return "", fmt.Errorf("cluster %q is not defined in the provider configuration", clusterName)
+	}
+	return pem, nil
+}
+
+// resourceProviderData returns the providerData handed to resources, nil before the provider is
+// configured.
+func resourceProviderData(req resource.ConfigureRequest, resp *resource.ConfigureResponse) *providerData {
+	if req.ProviderData == nil {
+		return nil
+	}
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected provider data", fmt.Sprintf("expected *providerData, got %T", req.ProviderData))
+		return nil
+	}
+	return data
+}
+
+// publicKeyAttributes returns the public_key and cluster attributes of a resource sealing with a
+// single certificate, exactly one of them must be configured.
+func publicKeyAttributes(description string) (schema.StringAttribute, schema.StringAttribute) {
+	publicKeyAttr := schema.StringAttribute{
+		Optional: true,
+		Computed: true,
+		Validators: []validator.String{
+			stringvalidator.ExactlyOneOf(path.MatchRoot(publicKey), path.MatchRoot(clusters)),
+		},
+		Description: description + " Taken from the cluster of the provider configuration when cluster is set",
+	}
+	clusterAttr := schema.StringAttribute{
+		Optional:    true,
+		Description: "Name of a cluster of the provider configuration whose certificate is used instead of public_key",
+	}
+	return publicKeyAttr, clusterAttr
+}
+
+// modifyPlanPublicKey plans public_key from the cluster of the provider configuration when it is
+// not configured. When the certificate of the cluster changed, outputs are planned unknown as the
+// resource is sealed again.
+func modifyPlanPublicKey(ctx context.Context, data *providerData, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse, outputs ...path.Path) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var configured, clusterName, planned types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root(publicKey), &configured)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root(clusters), &clusterName)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root(publicKey), &planned)...)
+	if resp.Diagnostics.HasError() || !configured.IsNull() || clusterName.IsNull() {
+		return
+	}
+
+	resolved := types.StringUnknown()
+	if !clusterName.IsUnknown() {
+		pem, err := data.clusterCert(clusterName.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root(clusters), "Unknown cluster", err.Error())
+			return
+		}
+		resolved = types.StringValue(pem)
+	}
+	if resolved.Equal(planned) {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(publicKey), resolved)...)
+	for _, p := range outputs {
+		t, diags := req.Plan.Schema.TypeAtPath(ctx, p)
+		resp.Diagnostics.Append(diags...)
+		if diags.HasError() {
+			return
+		}
+		unknown, err := t.ValueFromTerraform(ctx, tftypes.NewValue(t.TerraformType(ctx), tftypes.UnknownValue))
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(p, "Failed to plan unknown value", err.Error())
+			return
+		}
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, p, unknown)...)
+	}
+}
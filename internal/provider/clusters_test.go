@@ -0,0 +1,190 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	fpath "path/filepath"
+	"testing"
+
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/fakecontroller"
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/k8s"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func testCluster(clusterName string) clusterModel {
+	return clusterModel{
+		Name:                types.StringValue(clusterName),
+		Cert:                types.StringNull(),
+		CertFile:            types.StringNull(),
+		CertURL:             types.StringNull(),
+		FetchFromController: types.BoolNull(),
+		ControllerName:      types.StringNull(),
+		ControllerNamespace: types.StringNull(),
+	}
+}
+
+func TestLoadClusters(t *testing.T) {
+	certAA, certBB := testCertPEM(t), testCertPEM(t)
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"cert.pem": certBB})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/cert.pem" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(certAA))
+	}))
+	defer server.Close()
+	controller, err := fakecontroller.New(fakecontroller.WithService("name-aa", "ns-aa"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer controller.Close()
+	client, err := k8s.NewClient(controller.Config())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withCert := func(c clusterModel, pem string) clusterModel {
+		c.Cert = types.StringValue(pem)
+		return c
+	}
+	type diagnostic struct {
+		Path    path.Path
+		Summary string
+	}
+	tests := []struct {
+		Name                string
+		Config              []clusterModel
+		Client              *k8s.Client
+		ExpectedClusters    map[string]string
+		ExpectedDiagnostics []diagnostic
+	}{
+		{
+			Name:             "cert",
+			Config:           []clusterModel{withCert(testCluster("clusterAA"), certAA)},
+			ExpectedClusters: map[string]string{"clusterAA": certAA},
+		},
+		{
+			Name: "cert_file and cert_url",
+			Config: func() []clusterModel {
+				fromFile, fromURL := testCluster("clusterAA"), testCluster("clusterBB")
+				fromFile.CertFile = types.StringValue(fpath.Join(dir, "cert.pem"))
+				fromURL.CertURL = types.StringValue(server.URL + "/cert.pem")
+				return []clusterModel{fromFile, fromURL}
+			}(),
+			ExpectedClusters: map[string]string{"clusterAA": certBB, "clusterBB": certAA},
+		},
+		{
+			Name: "fetch_from_controller",
+			Config: func() []clusterModel {
+				c := testCluster("clusterAA")
+				c.FetchFromController = types.BoolValue(true)
+				c.ControllerName = types.StringValue("name-aa")
+				c.ControllerNamespace = types.StringValue("ns-aa")
+				return []clusterModel{c}
+			}(),
+			Client:           client,
+			ExpectedClusters: map[string]string{"clusterAA": string(controller.CertPEM())},
+		},
+		{
+			Name: "fetch_from_controller without kubernetes block",
+			Config: func() []clusterModel {
+				c := testCluster("clusterAA")
+				c.FetchFromController = types.BoolValue(true)
+				return []clusterModel{c}
+			}(),
+			ExpectedClusters: map[string]string{},
+			ExpectedDiagnostics: []diagnostic{
+				{path.Root(clusters).AtListIndex(0).AtName(fetchFromController), "Failed to load cluster certificate"},
+			},
+		},
+		{
+			Name: "duplicate",
+			Config: []clusterModel{
+				withCert(testCluster("clusterAA"), certAA),
+				withCert(testCluster("clusterAA"), certBB),
+			},
+			ExpectedClusters: map[string]string{"clusterAA": certAA},
+			ExpectedDiagnostics: []diagnostic{
+				{path.Root(clusters).AtListIndex(1).AtName(name), "Duplicate cluster"},
+			},
+		},
+		{
+			Name: "unknown",
+			Config: func() []clusterModel {
+				c := testCluster("clusterAA")
+				c.Cert = types.StringUnknown()
+				return []clusterModel{c, withCert(testCluster("clusterBB"), certBB)}
+			}(),
+			ExpectedClusters: map[string]string{"clusterBB": certBB},
+			ExpectedDiagnostics: []diagnostic{
+				{path.Root(clusters).AtListIndex(0), "Unknown cluster configuration"},
+			},
+		},
+		{
+			Name: "no certificate source",
+			Config: func() []clusterModel {
+				c := testCluster("clusterAA")
+				c.FetchFromController = types.BoolValue(false)
+				return []clusterModel{c}
+			}(),
+			ExpectedClusters: map[string]string{},
+			ExpectedDiagnostics: []diagnostic{
+				{path.Root(clusters).AtListIndex(0), "Invalid cluster configuration"},
+			},
+		},
+		{
+			Name: "several certificate sources",
+			Config: func() []clusterModel {
+				c := withCert(testCluster("clusterAA"), certAA)
+				c.CertURL = types.StringValue(server.URL + "/cert.pem")
+				return []clusterModel{c}
+			}(),
+			ExpectedClusters: map[string]string{},
+			ExpectedDiagnostics: []diagnostic{
+				{path.Root(clusters).AtListIndex(0), "Invalid cluster configuration"},
+			},
+		},
+		{
+			Name: "missing cert_file and cert_url",
+			Config: func() []clusterModel {
+				fromFile, fromURL := testCluster("clusterAA"), testCluster("clusterBB")
+				fromFile.CertFile = types.StringValue(fpath.Join(dir, "missing.pem"))
+				fromURL.CertURL = types.StringValue(server.URL + "/missing.pem")
+				return []clusterModel{fromFile, fromURL}
+			}(),
+			ExpectedClusters: map[string]string{},
+			ExpectedDiagnostics: []diagnostic{
+				{path.Root(clusters).AtListIndex(0).AtName(certFile), "Failed to load cluster certificate"},
+				{path.Root(clusters).AtListIndex(1).AtName(certURL), "Failed to load cluster certificate"},
+			},
+		},
+		{
+			Name:             "invalid certificate",
+			Config:           []clusterModel{withCert(testCluster("clusterAA"), "-----BEGIN CERTIFICATE-----\nAA==\n-----END CERTIFICATE-----\n")},
+			ExpectedClusters: map[string]string{},
+			ExpectedDiagnostics: []diagnostic{
+				{path.Root(clusters).AtListIndex(0).AtName(cert), "Invalid cluster certificate"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			data := &providerData{Client: tc.Client}
+			diags := loadClusters(context.Background(), tc.Config, data)
+
+			var got []diagnostic
+			for _, d := range diags {
+				p, _ := d.(interface{ Path() path.Path })
+				got = append(got, diagnostic{p.Path(), d.Summary()})
+			}
+			assert.Equal(t, tc.ExpectedDiagnostics, got)
+			assert.Equal(t, tc.ExpectedClusters, data.Clusters)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/k8s"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// controllerService caches the controller Service discovered for a provider configuration.
+type controllerService struct {
+	mu        sync.Mutex
+	name      string
+	namespace string
+}
+
+// controller returns the name and namespace of the controller Service. When the provider sets
+// neither controller_name nor controller_namespace, the Service is discovered by its labels on
+// first use. Clusters where listing Services is forbidden fall back to the defaults, which are not
+// cached so that discovery is tried again once permissions are granted.
+func (d *providerData) controller(ctx context.Context) (string, string, error) {
+	if d.ControllerName != "" || d.ControllerNamespace != "" {
+		return d.ControllerName, d.ControllerNamespace, nil
+	}
+
+	d.controllerService.mu.Lock()
+	defer d.controllerService.mu.Unlock()
+	if d.controllerService.name != "" {
+		return d.controllerService.name, d.controllerService.namespace, nil
+	}
+
+	name, namespace, err := d.Client.DiscoverController(ctx)
+	switch {
+	case errors.Is(err, k8s.ErrControllerNotFound) || errors.Is(err, k8s.ErrAmbiguousController):
+		return "", "", fmt.Errorf("%w, set %s and %s", err, controllerName, controllerNamespace)
+	case apierrors.IsForbidden(err):
+		tflog.Warn(ctx, "Cannot discover the controller, using the default service", map[string]interface{}{"error": err.Error()})
+		return k8s.DefaultControllerName, k8s.DefaultControllerNamespace, nil
+	case err != nil:
+		return "", "", fmt.Errorf("unable to discover the controller: %w", err)
+	default:
+		tflog.Debug(ctx, "Discovered the controller", map[string]interface{}{"name": name, "namespace": namespace})
+	}
+
+	d.controllerService.name, d.controllerService.namespace = name, namespace
+	return name, namespace, nil
+}
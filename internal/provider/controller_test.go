@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/k8s"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestController(t *testing.T) {
+	tests := []struct {
+		Name              string
+		Response          func(t *testing.T) *http.Response
+		ExpectedName      string
+		ExpectedNamespace string
+		ExpectedErr       bool
+		ExpectedCached    bool
+	}{
+		{
+			Name: "discovered",
+			Response: func(t *testing.T) *http.Response {
+				return jsonResponse(t, http.StatusOK, v1.ServiceList{
+					TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceList"},
+					Items: []v1.Service{{
+						ObjectMeta: metav1.ObjectMeta{Name: "name-aa", Namespace: "ns-aa"},
+						Spec:       v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 8080}}},
+					}},
+				})
+			},
+			ExpectedName:      "name-aa",
+			ExpectedNamespace: "ns-aa",
+			ExpectedCached:    true,
+		},
+		{
+			Name: "not found",
+			Response: func(t *testing.T) *http.Response {
+				return jsonResponse(t, http.StatusOK, v1.ServiceList{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceList"}})
+			},
+			ExpectedErr: true,
+		},
+		{
+			Name: "forbidden",
+			Response: func(t *testing.T) *http.Response {
+				return statusResponse(t, http.StatusForbidden, metav1.StatusReasonForbidden)
+			},
+			ExpectedName:      k8s.DefaultControllerName,
+			ExpectedNamespace: k8s.DefaultControllerNamespace,
+		},
+		{
+			Name: "server error",
+			Response: func(t *testing.T) *http.Response {
+				return statusResponse(t, http.StatusInternalServerError, metav1.StatusReasonInternalError)
+			},
+			ExpectedErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := context.Background()
+			requests := 0
+			data := &providerData{Client: testClient(t, func(req *http.Request) (*http.Response, error) {
+				requests++
+				return tc.Response(t), nil
+			})}
+
+			var discovered int
+			for i := 0; i < 2; i++ {
+				name, namespace, err := data.controller(ctx)
+				assert.Equal(t, tc.ExpectedErr, err != nil, err)
+				assert.Equal(t, tc.ExpectedName, name)
+				assert.Equal(t, tc.ExpectedNamespace, namespace)
+				if i == 0 {
+					discovered = requests
+				}
+			}
+			// only a discovered Service is cached, anything else is discovered again
+			assert.Equal(t, tc.ExpectedCached, requests == discovered)
+		})
+	}
+}
+
+func TestControllerConfigured(t *testing.T) {
+	data := &providerData{ControllerName: "name-aa"}
+	name, namespace, err := data.controller(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "name-aa", name)
+	assert.Equal(t, "", namespace)
+}
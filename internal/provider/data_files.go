@@ -0,0 +1,104 @@
+package provider
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/k8s"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type dataFile struct {
+	attr  string
+	path  types.String
+	parse func([]byte) (map[string]string, error)
+}
+
+// dataFiles returns the env_file, json_file and yaml_file attributes which are set.
+func dataFiles(plan *sealedSecretModel) []dataFile {
+	var set []dataFile
+	for _, f := range []dataFile{
+		{envFile, plan.EnvFile, k8s.ParseEnvFile},
+		{jsonFile, plan.JSONFile, k8s.ParseJSONFile},
+		{yamlFile, plan.YAMLFile, k8s.ParseYAMLFile},
+	} {
+		if f.path.ValueString() != "" {
+			set = append(set, f)
+		}
+	}
+	return set
+}
+
+// readDataFiles reads the secret keys of the env_file, json_file and yaml_file attributes. It
+// returns the values along with the attribute each key was read from, and the SHA-256 of every
+// file keyed by attribute. A key may only be set by one file.
+func readDataFiles(plan *sealedSecretModel) (map[string]string, map[string]string, map[string]string, error) {
+	values := make(map[string]string)
+	sources := make(map[string]string)
+	hashes := make(map[string]string)
+	for _, f := range dataFiles(plan) {
+		content, err := os.ReadFile(f.path.ValueString())
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("%s: %w", f.attr, err)
+		}
+		hashes[f.attr] = hashBytes(content)
+		fileValues, err := f.parse(content)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("%s %s: %w", f.attr, f.path.ValueString(), err)
+		}
+		for k, v := range fileValues {
+			if err := k8s.ValidateKey(k); err != nil {
+				return nil, nil, nil, fmt.Errorf("%s %s: %w", f.attr, f.path.ValueString(), err)
+			}
+			if source, ok := sources[k]; ok {
+				return nil, nil, nil, fmt.Errorf("key %q is set in both %s and %s", k, source, f.attr)
+			}
+			values[k] = v
+			sources[k] = f.attr
+		}
+	}
+	return values, sources, hashes, nil
+}
+
+// hashDataFiles returns the SHA-256 of the env_file, json_file and yaml_file attributes keyed by
+// attribute, without parsing them.
+func hashDataFiles(plan *sealedSecretModel) (map[string]string, error) {
+	hashes := make(map[string]string)
+	for _, f := range dataFiles(plan) {
+		hash, err := fileHash(f.path.ValueString())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.attr, err)
+		}
+		hashes[f.attr] = hash
+	}
+	return hashes, nil
+}
+
+// readFiles reads the raw content of the files attribute along with the SHA-256 of every file,
+// both keyed by secret key.
+func readFiles(files types.Map) (map[string][]byte, map[string]string, error) {
+	paths, err := tfMaptoMapStringString(files)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to convert files tf map to map[string]string: %w", err)
+	}
+	contents := make(map[string][]byte, len(paths))
+	hashes := make(map[string]string, len(paths))
+	for k, p := range paths {
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return nil, nil, fmt.Errorf("files: key %q: %w", k, err)
+		}
+		contents[k] = content
+		hashes[k] = hashBytes(content)
+	}
+	return contents, hashes, nil
+}
+
+// fileHashesToMap is null when there are no files, so that secrets without files keep a null
+// files_sha256 and data_files_sha256.
+func fileHashesToMap(hashes map[string]string) types.Map {
+	if len(hashes) == 0 {
+		return types.MapNull(types.StringType)
+	}
+	return hashesToMap(hashes)
+}
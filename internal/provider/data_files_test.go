@@ -0,0 +1,217 @@
+package provider
+
+import (
+	"context"
+	"os"
+	fpath "path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadDataFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"secret.env":  "keyAA=valueAA\n",
+		"secret.json": `{"keyBB": "valueBB"}`,
+	})
+	plan := &sealedSecretModel{
+		EnvFile:  types.StringValue(fpath.Join(dir, "secret.env")),
+		JSONFile: types.StringValue(fpath.Join(dir, "secret.json")),
+		YAMLFile: types.StringNull(),
+	}
+
+	values, sources, hashes, err := readDataFiles(plan)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"keyAA": "valueAA", "keyBB": "valueBB"}, values)
+	assert.Equal(t, map[string]string{"keyAA": envFile, "keyBB": jsonFile}, sources)
+	expectedHashes := map[string]string{
+		envFile:  hashBytes([]byte("keyAA=valueAA\n")),
+		jsonFile: hashBytes([]byte(`{"keyBB": "valueBB"}`)),
+	}
+	assert.Equal(t, expectedHashes, hashes)
+
+	hashes, err = hashDataFiles(plan)
+	assert.Nil(t, err)
+	assert.Equal(t, expectedHashes, hashes)
+
+	assert.Nil(t, os.Remove(fpath.Join(dir, "secret.json")))
+	_, err = hashDataFiles(plan)
+	assert.ErrorContains(t, err, "json_file: ")
+	_, _, _, err = readDataFiles(plan)
+	assert.ErrorContains(t, err, "json_file: ")
+}
+
+func TestReadFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"fileAA": "contentAA", "fileBB": "\x00\xff"})
+
+	contents, hashes, err := readFiles(types.MapValueMust(types.StringType, map[string]attr.Value{
+		"keyAA": types.StringValue(fpath.Join(dir, "fileAA")),
+		"keyBB": types.StringValue(fpath.Join(dir, "fileBB")),
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]byte{"keyAA": []byte("contentAA"), "keyBB": []byte("\x00\xff")}, contents)
+	assert.Equal(t, map[string]string{
+		"keyAA": hashBytes([]byte("contentAA")),
+		"keyBB": hashBytes([]byte("\x00\xff")),
+	}, hashes)
+
+	contents, hashes, err = readFiles(types.MapNull(types.StringType))
+	assert.Nil(t, err)
+	assert.Empty(t, contents)
+	assert.Empty(t, hashes)
+
+	_, _, err = readFiles(types.MapValueMust(types.StringType, map[string]attr.Value{
+		"keyCC": types.StringValue(fpath.Join(dir, "fileCC")),
+	}))
+	assert.ErrorContains(t, err, `files: key "keyCC": `)
+}
+
+// sealedSecretState returns a state of the sealedsecret resource with attrs set, the other
+// attributes being null.
+func sealedSecretState(ctx context.Context, t *testing.T, attrs map[string]attr.Value) tfsdk.State {
+	var schemaResp resource.SchemaResponse
+	(&sealedSecretResource{}).Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+	state := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}
+	for k, v := range attrs {
+		if diags := state.SetAttribute(ctx, path.Root(k), v); diags.HasError() {
+			t.Fatal(diags)
+		}
+	}
+	return state
+}
+
+func TestModifyPlanFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"fileAA": "contentAA", "secret.env": "keyBB=valueBB\n"})
+	fileAA := types.StringValue(fpath.Join(dir, "fileAA"))
+	envFileAA := types.StringValue(fpath.Join(dir, "secret.env"))
+	missing := types.StringValue(fpath.Join(dir, "missing"))
+	hashesOf := func(key, content string) types.Map {
+		return types.MapValueMust(types.StringType, map[string]attr.Value{key: types.StringValue(hashBytes([]byte(content)))})
+	}
+	unknown := types.MapUnknown(types.StringType)
+	null := types.MapNull(types.StringType)
+
+	tests := []struct {
+		Name                    string
+		Files                   types.Map
+		EnvFile                 types.String
+		State                   map[string]attr.Value
+		ExpectedFilesSHA256     types.Map
+		ExpectedDataFilesSHA256 types.Map
+		ExpectedRequiresReplace path.Paths
+	}{
+		{
+			Name:                    "no files",
+			Files:                   null,
+			EnvFile:                 types.StringNull(),
+			ExpectedFilesSHA256:     null,
+			ExpectedDataFilesSHA256: null,
+		},
+		{
+			Name:                    "readable files",
+			Files:                   types.MapValueMust(types.StringType, map[string]attr.Value{"keyAA": fileAA}),
+			EnvFile:                 envFileAA,
+			ExpectedFilesSHA256:     hashesOf("keyAA", "contentAA"),
+			ExpectedDataFilesSHA256: hashesOf(envFile, "keyBB=valueBB\n"),
+		},
+		{
+			Name:                    "unreadable files",
+			Files:                   types.MapValueMust(types.StringType, map[string]attr.Value{"keyAA": missing}),
+			EnvFile:                 missing,
+			ExpectedFilesSHA256:     unknown,
+			ExpectedDataFilesSHA256: unknown,
+		},
+		{
+			Name:                    "unknown paths",
+			Files:                   unknown,
+			EnvFile:                 types.StringUnknown(),
+			ExpectedFilesSHA256:     unknown,
+			ExpectedDataFilesSHA256: unknown,
+		},
+		{
+			Name:    "changed content",
+			Files:   types.MapValueMust(types.StringType, map[string]attr.Value{"keyAA": fileAA}),
+			EnvFile: envFileAA,
+			State: map[string]attr.Value{
+				filesSHA256:     hashesOf("keyAA", "contentZZ"),
+				dataFilesSHA256: hashesOf(envFile, "keyBB=valueZZ\n"),
+			},
+			ExpectedFilesSHA256:     hashesOf("keyAA", "contentAA"),
+			ExpectedDataFilesSHA256: hashesOf(envFile, "keyBB=valueBB\n"),
+		},
+		{
+			Name:    "changed content of an immutable secret",
+			Files:   types.MapValueMust(types.StringType, map[string]attr.Value{"keyAA": fileAA}),
+			EnvFile: envFileAA,
+			State: map[string]attr.Value{
+				immutable:       types.BoolValue(true),
+				filesSHA256:     hashesOf("keyAA", "contentZZ"),
+				dataFilesSHA256: hashesOf(envFile, "keyBB=valueZZ\n"),
+			},
+			ExpectedFilesSHA256:     hashesOf("keyAA", "contentAA"),
+			ExpectedDataFilesSHA256: hashesOf(envFile, "keyBB=valueBB\n"),
+			ExpectedRequiresReplace: path.Paths{path.Root(filesSHA256), path.Root(dataFilesSHA256)},
+		},
+		{
+			Name:    "unchanged content of an immutable secret",
+			Files:   types.MapValueMust(types.StringType, map[string]attr.Value{"keyAA": fileAA}),
+			EnvFile: envFileAA,
+			State: map[string]attr.Value{
+				immutable:       types.BoolValue(true),
+				filesSHA256:     hashesOf("keyAA", "contentAA"),
+				dataFilesSHA256: hashesOf(envFile, "keyBB=valueBB\n"),
+			},
+			ExpectedFilesSHA256:     hashesOf("keyAA", "contentAA"),
+			ExpectedDataFilesSHA256: hashesOf(envFile, "keyBB=valueBB\n"),
+		},
+		{
+			Name:    "immutable secret without data_files_sha256 in state",
+			Files:   null,
+			EnvFile: envFileAA,
+			State: map[string]attr.Value{
+				immutable: types.BoolValue(true),
+			},
+			ExpectedFilesSHA256:     null,
+			ExpectedDataFilesSHA256: hashesOf(envFile, "keyBB=valueBB\n"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := context.Background()
+			attrs := map[string]attr.Value{files: tc.Files, envFile: tc.EnvFile}
+			planned := sealedSecretState(ctx, t, attrs)
+			plan := tfsdk.Plan{Schema: planned.Schema, Raw: planned.Raw}
+			state := tfsdk.State{Schema: planned.Schema, Raw: tftypes.NewValue(planned.Raw.Type(), nil)}
+			if tc.State != nil {
+				for k, v := range attrs {
+					tc.State[k] = v
+				}
+				state = sealedSecretState(ctx, t, tc.State)
+			}
+
+			resp := resource.ModifyPlanResponse{Plan: plan}
+			(&sealedSecretResource{}).modifyPlanFiles(ctx, resource.ModifyPlanRequest{Plan: plan, State: state}, &resp)
+			assert.False(t, resp.Diagnostics.HasError(), resp.Diagnostics)
+
+			var filesHashes, dataFilesHashes types.Map
+			resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root(filesSHA256), &filesHashes)...)
+			resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root(dataFilesSHA256), &dataFilesHashes)...)
+			assert.Equal(t, tc.ExpectedFilesSHA256, filesHashes)
+			assert.Equal(t, tc.ExpectedDataFilesSHA256, dataFilesHashes)
+			assert.Equal(t, tc.ExpectedRequiresReplace, resp.RequiresReplace)
+		})
+	}
+}
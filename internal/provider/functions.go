@@ -0,0 +1,196 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/kubeseal"
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ function.Function = &sealValueFunction{}
+	_ function.Function = &fingerprintFunction{}
+	_ function.Function = &parseSealedFunction{}
+)
+
+func NewSealValueFunction() function.Function {
+	return &sealValueFunction{}
+}
+
+func NewFingerprintFunction() function.Function {
+	return &fingerprintFunction{}
+}
+
+func NewParseSealedFunction() function.Function {
+	return &parseSealedFunction{}
+}
+
+type sealValueFunction struct{}
+
+func (f *sealValueFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "seal_value"
+}
+
+func (f *sealValueFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Seal a single value",
+		Description: "Encrypts a single value like kubeseal --raw, returning the ciphertext to use in the encryptedData of a SealedSecret. " +
+			"The value can only be unsealed into a secret matching the name, namespace and scope it was sealed for",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "cert",
+				Description: "PEM encoded certificate of the controller",
+			},
+			function.StringParameter{
+				Name:        "name",
+				Description: "name of the secret, may be empty unless the scope is strict",
+			},
+			function.StringParameter{
+				Name:        "namespace",
+				Description: "namespace of the secret, may be empty if the scope is cluster-wide",
+			},
+			function.StringParameter{
+				Name:        "scope",
+				Description: "scope of the sealed secret: strict, namespace-wide, cluster-wide. Empty means strict",
+			},
+			function.StringParameter{
+				Name:        "value",
+				Description: "value to seal",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *sealValueFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var cert, name, namespace, scopeName, value string
+
+	resp.Error = req.Arguments.Get(ctx, &cert, &name, &namespace, &scopeName, &value)
+	if resp.Error != nil {
+		return
+	}
+
+	pk, err := kubeseal.ParsePublicKey([]byte(cert))
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, "failed to parse public key: "+err.Error())
+		return
+	}
+	var s ssv1alpha1.SealingScope
+	if err := s.Set(scopeName); err != nil {
+		resp.Error = function.NewArgumentFuncError(3, "invalid scope: "+err.Error())
+		return
+	}
+
+	sealed, err := kubeseal.NewSealer(kubeseal.StaticKey(pk)).SealValue(ctx, name, namespace, s, []byte(value))
+	if err != nil {
+		resp.Error = function.NewFuncError(err.Error())
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, sealed)
+}
+
+type fingerprintFunction struct{}
+
+func (f *fingerprintFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "fingerprint"
+}
+
+func (f *fingerprintFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Fingerprint of a certificate",
+		Description: "Returns the SHA-256 fingerprint of the public key of a certificate, as used by the controller to identify its sealing keys",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "cert",
+				Description: "PEM encoded certificate of the controller",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *fingerprintFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var cert string
+
+	resp.Error = req.Arguments.Get(ctx, &cert)
+	if resp.Error != nil {
+		return
+	}
+
+	pk, err := kubeseal.ParsePublicKey([]byte(cert))
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, "failed to parse public key: "+err.Error())
+		return
+	}
+	fingerprint, err := kubeseal.Fingerprint(pk)
+	if err != nil {
+		resp.Error = function.NewFuncError(err.Error())
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, fingerprint)
+}
+
+type parseSealedFunction struct{}
+
+// parsedSealedSecretModel is the object returned by parse_sealed.
+type parsedSealedSecretModel struct {
+	Name      string   `tfsdk:"name"`
+	Namespace string   `tfsdk:"namespace"`
+	Scope     string   `tfsdk:"scope"`
+	Type      string   `tfsdk:"type"`
+	Keys      []string `tfsdk:"keys"`
+}
+
+func (f *parseSealedFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "parse_sealed"
+}
+
+func (f *parseSealedFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Inspect a SealedSecret manifest",
+		Description: "Decodes a SealedSecret manifest, returning its name, namespace, scope, secret type and the sorted keys of its encryptedData",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "manifest",
+				Description: "SealedSecret manifest as YAML or JSON",
+			},
+		},
+		Return: function.ObjectReturn{
+			AttributeTypes: map[string]attr.Type{
+				name:       types.StringType,
+				namespace:  types.StringType,
+				scope:      types.StringType,
+				secretType: types.StringType,
+				"keys":     types.ListType{ElemType: types.StringType},
+			},
+		},
+	}
+}
+
+func (f *parseSealedFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var manifest string
+
+	resp.Error = req.Arguments.Get(ctx, &manifest)
+	if resp.Error != nil {
+		return
+	}
+
+	summary, err := kubeseal.Inspect([]byte(manifest))
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, parsedSealedSecretModel{
+		Name:      summary.Name,
+		Namespace: summary.Namespace,
+		Scope:     summary.Scope,
+		Type:      summary.Type,
+		Keys:      summary.Keys,
+	})
+}
@@ -0,0 +1,164 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/fakecontroller"
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/kubeseal"
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// runFunction runs f with the arguments, its result starting unknown like the framework does.
+func runFunction(ctx context.Context, f function.Function, args ...attr.Value) function.RunResponse {
+	var definition function.DefinitionResponse
+	f.Definition(ctx, function.DefinitionRequest{}, &definition)
+	result, _ := definition.Definition.Return.NewResultData(ctx)
+	resp := function.RunResponse{Result: result}
+	f.Run(ctx, function.RunRequest{Arguments: function.NewArgumentsData(args)}, &resp)
+	return resp
+}
+
+func TestSealValueFunction(t *testing.T) {
+	ctx := context.Background()
+	controller, err := fakecontroller.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer controller.Close()
+	cert := types.StringValue(string(controller.CertPEM()))
+
+	tests := []struct {
+		Name          string
+		Cert          types.String
+		Scope         string
+		ExpectedScope ssv1alpha1.SealingScope
+		ExpectedError *function.FuncError
+	}{
+		{
+			Name:          "empty scope",
+			Cert:          cert,
+			ExpectedScope: ssv1alpha1.StrictScope,
+		},
+		{
+			Name:          "strict",
+			Cert:          cert,
+			Scope:         "strict",
+			ExpectedScope: ssv1alpha1.StrictScope,
+		},
+		{
+			Name:          "namespace-wide",
+			Cert:          cert,
+			Scope:         "namespace-wide",
+			ExpectedScope: ssv1alpha1.NamespaceWideScope,
+		},
+		{
+			Name:          "cluster-wide",
+			Cert:          cert,
+			Scope:         "cluster-wide",
+			ExpectedScope: ssv1alpha1.ClusterWideScope,
+		},
+		{
+			Name:          "invalid scope",
+			Cert:          cert,
+			Scope:         "global",
+			ExpectedError: function.NewArgumentFuncError(3, "invalid scope: must be one of: strict, namespace-wide, cluster-wide"),
+		},
+		{
+			Name:          "invalid cert",
+			Cert:          types.StringValue("cert"),
+			ExpectedError: function.NewArgumentFuncError(0, "failed to parse public key: data does not contain any valid RSA or ECDSA certificates"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			resp := runFunction(ctx, &sealValueFunction{}, tc.Cert, types.StringValue("name-aa"), types.StringValue("ns-aa"), types.StringValue(tc.Scope), types.StringValue("valueAA"))
+			if tc.ExpectedError != nil {
+				assert.Equal(t, tc.ExpectedError, resp.Error)
+				return
+			}
+			assert.Nil(t, resp.Error)
+
+			// the controller only unseals the value into a secret of the scope it was sealed for
+			sealed := resp.Result.Value().(types.String)
+			manifest, err := json.Marshal(ssv1alpha1.SealedSecret{
+				TypeMeta: metav1.TypeMeta{APIVersion: "bitnami.com/v1alpha1", Kind: "SealedSecret"},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "name-aa",
+					Namespace:   "ns-aa",
+					Annotations: ssv1alpha1.UpdateScopeAnnotations(nil, tc.ExpectedScope),
+				},
+				Spec: ssv1alpha1.SealedSecretSpec{EncryptedData: map[string]string{"keyAA": sealed.ValueString()}},
+			})
+			assert.Nil(t, err)
+			secret, err := controller.Unseal(manifest)
+			assert.Nil(t, err)
+			assert.Equal(t, []byte("valueAA"), secret.Data["keyAA"])
+		})
+	}
+}
+
+func TestFingerprintFunction(t *testing.T) {
+	ctx := context.Background()
+	certAA := testCertPEM(t)
+	fingerprintAA, err := publicKeyFingerprint(certAA)
+	assert.Nil(t, err)
+
+	resp := runFunction(ctx, &fingerprintFunction{}, types.StringValue(certAA))
+	assert.Nil(t, resp.Error)
+	assert.Equal(t, types.StringValue(fingerprintAA), resp.Result.Value())
+
+	resp = runFunction(ctx, &fingerprintFunction{}, types.StringValue("cert"))
+	assert.Equal(t, function.NewArgumentFuncError(0, "failed to parse public key: data does not contain any valid RSA or ECDSA certificates"), resp.Error)
+}
+
+func TestParseSealedFunction(t *testing.T) {
+	ctx := context.Background()
+	pk, err := kubeseal.ParsePublicKey([]byte(testCertPEM(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := kubeseal.NewSealer(kubeseal.StaticKey(pk), kubeseal.WithFormat(kubeseal.FormatJSON)).Seal(ctx, v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "name-aa",
+			Namespace:   "ns-aa",
+			Annotations: ssv1alpha1.UpdateScopeAnnotations(nil, ssv1alpha1.NamespaceWideScope),
+		},
+		Type:       v1.SecretTypeBasicAuth,
+		StringData: map[string]string{v1.BasicAuthUsernameKey: "valueAA", v1.BasicAuthPasswordKey: "valueBB"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := runFunction(ctx, &parseSealedFunction{}, types.StringValue(string(manifest)))
+	assert.Nil(t, resp.Error)
+	assert.Equal(t, types.ObjectValueMust(
+		map[string]attr.Type{
+			name:       types.StringType,
+			namespace:  types.StringType,
+			scope:      types.StringType,
+			secretType: types.StringType,
+			"keys":     types.ListType{ElemType: types.StringType},
+		},
+		map[string]attr.Value{
+			name:       types.StringValue("name-aa"),
+			namespace:  types.StringValue("ns-aa"),
+			scope:      types.StringValue("namespace-wide"),
+			secretType: types.StringValue(string(v1.SecretTypeBasicAuth)),
+			"keys":     stringsToList([]string{v1.BasicAuthPasswordKey, v1.BasicAuthUsernameKey}),
+		},
+	), resp.Result.Value())
+
+	resp = runFunction(ctx, &parseSealedFunction{}, types.StringValue("kind: Secret"))
+	assert.Equal(t, int64(0), *resp.Error.FunctionArgument)
+	assert.Contains(t, resp.Error.Text, "unable to decode sealed secret manifest")
+}
@@ -0,0 +1,139 @@
+package provider
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/kubeseal"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	secretKeys    = "keys"
+	keyDigests    = "key_digests"
+	keyDigestSalt = "key_digest_salt"
+)
+
+func newKeyDigestSalt() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate the salt of %s: %w", keyDigests, err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// setKeyInventory records the keys of secret and the digest of their values in plan, generating
+// the salt when the plan has none yet.
+func setKeyInventory(plan *sealedSecretModel, secret *v1.Secret) error {
+	if plan.KeyDigestSalt.IsNull() || plan.KeyDigestSalt.IsUnknown() {
+		salt, err := newKeyDigestSalt()
+		if err != nil {
+			return err
+		}
+		plan.KeyDigestSalt = types.StringValue(salt)
+	}
+
+	keys, digests := kubeseal.KeyDigests(secret, []byte(plan.KeyDigestSalt.ValueString()))
+	plan.Keys = stringsToList(keys)
+	plan.KeyDigests = hashesToMap(digests)
+	return nil
+}
+
+func stringsToList(values []string) types.List {
+	elems := make([]attr.Value, len(values))
+	for i, v := range values {
+		elems[i] = types.StringValue(v)
+	}
+	return types.ListValueMust(types.StringType, elems)
+}
+
+// modifyPlanSecret builds the secret at plan time, so that invalid input fails the plan rather than
+// the apply or the controller, and plans keys and key_digests from it to show which keys are added,
+// removed or changed. They stay unknown until the attributes of the secret are known and its files
+// exist, key_digests also until the salt is generated.
+func (r *sealedSecretResource) modifyPlanSecret(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	var plan sealedSecretModel
+	resp.Diagnostics.Append(resp.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// the salt is kept for the lifetime of the resource so that digests only change with values.
+	// Without one it is generated by the apply, as a salt generated here would differ from the one
+	// of the plan Terraform makes again during the apply.
+	salt := types.StringUnknown()
+	if !req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root(keyDigestSalt), &salt)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if salt.IsNull() {
+			salt = types.StringUnknown()
+		}
+	}
+	plan.KeyDigestSalt = salt
+	plan.Keys = types.ListUnknown(types.StringType)
+	plan.KeyDigests = types.MapUnknown(types.StringType)
+
+	if secretConfigKnown(req.Config) {
+		candidate := plan
+		ctx, secret, _, err := buildSecret(ctx, &candidate)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			// files created by the same apply
+			tflog.Debug(ctx, "Secret cannot be built at plan time", map[string]interface{}{"error": err.Error()})
+		case err != nil:
+			resp.Diagnostics.AddError("Invalid secret", err.Error())
+			return
+		default:
+			if !plan.PublicKey.IsUnknown() {
+				if pk, err := kubeseal.ParsePublicKey([]byte(plan.PublicKey.ValueString())); err == nil {
+					if err := kubeseal.ValidateSealedSize(pk, &secret); err != nil {
+						resp.Diagnostics.AddError("Invalid secret", err.Error())
+						return
+					}
+				}
+			}
+			if plan.KeyDigestSalt.IsUnknown() {
+				keys, _ := kubeseal.KeyDigests(&secret, nil)
+				plan.Keys = stringsToList(keys)
+			} else if err := setKeyInventory(&plan, &secret); err != nil {
+				resp.Diagnostics.AddError("Failed to plan key digests", err.Error())
+				return
+			}
+		}
+	}
+
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(keyDigestSalt), plan.KeyDigestSalt)...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(secretKeys), plan.Keys)...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(keyDigests), plan.KeyDigests)...)
+}
+
+// secretConfigKnown reports whether the attributes the secret is built from are known, the key it
+// is sealed with is not needed.
+func secretConfigKnown(config tfsdk.Config) bool {
+	var attrs map[string]tftypes.Value
+	if err := config.Raw.As(&attrs); err != nil {
+		return false
+	}
+	for k, v := range attrs {
+		if k == publicKey || k == clusters || k == resealOnKeyRenewal {
+			continue
+		}
+		if !v.IsFullyKnown() {
+			return false
+		}
+	}
+	return true
+}
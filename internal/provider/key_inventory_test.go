@@ -0,0 +1,38 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/kubeseal"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestSetKeyInventory(t *testing.T) {
+	secret := &v1.Secret{
+		Data:       map[string][]byte{"keyBB": []byte("valueBB")},
+		StringData: map[string]string{"keyAA": "valueAA"},
+	}
+
+	// the salt of the state is kept
+	plan := sealedSecretModel{KeyDigestSalt: types.StringValue("saltAA")}
+	assert.Nil(t, setKeyInventory(&plan, secret))
+	_, digests := kubeseal.KeyDigests(secret, []byte("saltAA"))
+	assert.Equal(t, types.StringValue("saltAA"), plan.KeyDigestSalt)
+	assert.Equal(t, stringsToList([]string{"keyAA", "keyBB"}), plan.Keys)
+	assert.Equal(t, hashesToMap(digests), plan.KeyDigests)
+
+	// a salt is generated when the plan has none, a different one every time
+	for _, salt := range []types.String{types.StringNull(), types.StringUnknown()} {
+		plan := sealedSecretModel{KeyDigestSalt: salt}
+		assert.Nil(t, setKeyInventory(&plan, secret))
+		assert.Len(t, plan.KeyDigestSalt.ValueString(), 64)
+		assert.Equal(t, stringsToList([]string{"keyAA", "keyBB"}), plan.Keys)
+		assert.NotEqual(t, hashesToMap(digests), plan.KeyDigests)
+
+		other := sealedSecretModel{KeyDigestSalt: salt}
+		assert.Nil(t, setKeyInventory(&other, secret))
+		assert.NotEqual(t, plan.KeyDigestSalt, other.KeyDigestSalt)
+	}
+}
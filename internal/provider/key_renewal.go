@@ -0,0 +1,132 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	resealOnKeyRenewal = "reseal_on_key_renewal"
+	stale              = "stale"
+)
+
+// controllerCert caches the current certificate of the controller for the lifetime of a provider
+// configuration, so that a refresh of many resources fetches it once.
+type controllerCert struct {
+	mu          sync.Mutex
+	pem         string
+	fingerprint string
+}
+
+// currentCert returns the certificate the controller currently seals with and its fingerprint. It
+// requires the kubernetes block of the provider.
+func (d *providerData) currentCert(ctx context.Context) (string, string, error) {
+	if d == nil || d.Client == nil {
+		return "", "", fmt.Errorf("the kubernetes block of the provider is not configured")
+	}
+
+	d.controllerCert.mu.Lock()
+	defer d.controllerCert.mu.Unlock()
+	if d.controllerCert.pem != "" {
+		return d.controllerCert.pem, d.controllerCert.fingerprint, nil
+	}
+
+	controller, controllerNs, err := d.controller(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	pem, err := d.Client.Get(ctx, controller, controllerNs, "/v1/cert.pem")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to fetch the controller certificate: %w", err)
+	}
+	fingerprint, err := publicKeyFingerprint(string(pem))
+	if err != nil {
+		return "", "", fmt.Errorf("controller returned an invalid certificate: %w", err)
+	}
+
+	d.controllerCert.pem, d.controllerCert.fingerprint = string(pem), fingerprint
+	return d.controllerCert.pem, d.controllerCert.fingerprint, nil
+}
+
+// isStale reports whether the controller renewed its key since the secret was sealed with the key
+// of fingerprint. It is null without a controller connection or when the certificate cannot be
+// fetched, which is not worth failing an apply or a refresh for.
+func (r *sealedSecretResource) isStale(ctx context.Context, fingerprint types.String) types.Bool {
+	if r.provider == nil || r.provider.Client == nil || fingerprint.IsNull() || fingerprint.IsUnknown() {
+		return types.BoolNull()
+	}
+	_, current, err := r.provider.currentCert(ctx)
+	if err != nil {
+		tflog.Warn(ctx, "Cannot check the controller for a renewed key", map[string]interface{}{"error": err.Error()})
+		return types.BoolNull()
+	}
+	return types.BoolValue(current != fingerprint.ValueString())
+}
+
+// modifyPlanKeyRenewal plans public_key from the current certificate of the controller when
+// reseal_on_key_renewal is set, so that a renewed key seals the secret again.
+func (r *sealedSecretResource) modifyPlanKeyRenewal(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	var reseal types.Bool
+	var planned types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root(resealOnKeyRenewal), &reseal)...)
+	resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root(publicKey), &planned)...)
+	if resp.Diagnostics.HasError() || !reseal.ValueBool() {
+		return
+	}
+	if r.provider == nil || r.provider.Client == nil {
+		resp.Diagnostics.AddAttributeError(path.Root(resealOnKeyRenewal), "Missing controller connection",
+			resealOnKeyRenewal+" requires the kubernetes block of the provider to fetch the current certificate of the controller.")
+		return
+	}
+
+	pem, fingerprint, err := r.provider.currentCert(ctx)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root(resealOnKeyRenewal), "Failed to check for a renewed key", err.Error())
+		return
+	}
+	if !planned.IsUnknown() {
+		if plannedFingerprint, err := publicKeyFingerprint(planned.ValueString()); err == nil && plannedFingerprint == fingerprint {
+			return
+		}
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(publicKey), types.StringValue(pem))...)
+}
+
+// readKeyRenewal records in stale whether the controller renewed its key since the secret was
+// sealed, warning about it unless reseal_on_key_renewal seals it again anyway.
+func (r *sealedSecretResource) readKeyRenewal(ctx context.Context, state *sealedSecretModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	recorded := state.PublicKeyHash
+	// states written before public_key_hash existed are compared by their key
+	if recorded.IsNull() {
+		if fingerprint, err := publicKeyFingerprint(state.PublicKey.ValueString()); err == nil {
+			recorded = types.StringValue(fingerprint)
+		}
+	}
+
+	isStale := r.isStale(ctx, recorded)
+	if isStale.IsNull() {
+		return diags
+	}
+	state.Stale = isStale
+	if !isStale.ValueBool() || state.ResealOnKeyRenewal.ValueBool() {
+		return diags
+	}
+
+	_, current, _ := r.provider.currentCert(ctx)
+	diags.AddAttributeWarning(
+		path.Root(stale),
+		"Controller key renewed",
+		fmt.Sprintf("%s/%s is sealed with %s but the controller now seals with %s. Set %s or update the certificate to seal it with the current key.",
+			state.Namespace.ValueString(), state.Name.ValueString(), recorded.ValueString(), current, resealOnKeyRenewal),
+	)
+	return diags
+}
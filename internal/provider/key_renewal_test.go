@@ -0,0 +1,119 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/fakecontroller"
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/k8s"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newFakeController(t *testing.T) (*fakecontroller.Controller, *k8s.Client) {
+	c, err := fakecontroller.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+	client, err := k8s.NewClient(c.Config())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, client
+}
+
+// controllerResource returns a resource connected to the controller of client, with a certificate
+// cache of its own like a new provider configuration.
+func controllerResource(client *k8s.Client) *sealedSecretResource {
+	return &sealedSecretResource{provider: &providerData{
+		Client:              client,
+		ControllerName:      k8s.DefaultControllerName,
+		ControllerNamespace: k8s.DefaultControllerNamespace,
+	}}
+}
+
+func TestIsStale(t *testing.T) {
+	ctx := context.Background()
+	c, client := newFakeController(t)
+	sealedWith, err := publicKeyFingerprint(string(c.CertPEM()))
+	assert.Nil(t, err)
+
+	r := controllerResource(client)
+	assert.Equal(t, types.BoolValue(false), r.isStale(ctx, types.StringValue(sealedWith)))
+	assert.Equal(t, types.BoolNull(), r.isStale(ctx, types.StringNull()))
+	assert.Equal(t, types.BoolNull(), r.isStale(ctx, types.StringUnknown()))
+
+	assert.Nil(t, c.Rotate())
+	// the certificate is fetched once per provider configuration
+	assert.Equal(t, types.BoolValue(false), r.isStale(ctx, types.StringValue(sealedWith)))
+	assert.Equal(t, types.BoolValue(true), controllerResource(client).isStale(ctx, types.StringValue(sealedWith)))
+
+	// without a controller, staleness is unknown rather than an error
+	assert.Equal(t, types.BoolNull(), (&sealedSecretResource{}).isStale(ctx, types.StringValue(sealedWith)))
+	c.Close()
+	assert.Equal(t, types.BoolNull(), controllerResource(client).isStale(ctx, types.StringValue(sealedWith)))
+}
+
+func TestReadKeyRenewal(t *testing.T) {
+	c, client := newFakeController(t)
+	sealedPEM := string(c.CertPEM())
+	sealedWith, err := publicKeyFingerprint(sealedPEM)
+	assert.Nil(t, err)
+	assert.Nil(t, c.Rotate())
+
+	tests := []struct {
+		Name            string
+		State           sealedSecretModel
+		ExpectedStale   types.Bool
+		ExpectedWarning bool
+	}{
+		{
+			Name: "renewed",
+			State: sealedSecretModel{
+				PublicKeyHash:      types.StringValue(sealedWith),
+				ResealOnKeyRenewal: types.BoolNull(),
+			},
+			ExpectedStale:   types.BoolValue(true),
+			ExpectedWarning: true,
+		},
+		{
+			Name: "renewed and sealed again on apply",
+			State: sealedSecretModel{
+				PublicKeyHash:      types.StringValue(sealedWith),
+				ResealOnKeyRenewal: types.BoolValue(true),
+			},
+			ExpectedStale: types.BoolValue(true),
+		},
+		{
+			Name: "state written before public_key_hash",
+			State: sealedSecretModel{
+				PublicKey:          types.StringValue(sealedPEM),
+				PublicKeyHash:      types.StringNull(),
+				ResealOnKeyRenewal: types.BoolNull(),
+			},
+			ExpectedStale:   types.BoolValue(true),
+			ExpectedWarning: true,
+		},
+		{
+			Name: "current key",
+			State: sealedSecretModel{
+				PublicKey:          types.StringValue(string(c.CertPEM())),
+				PublicKeyHash:      types.StringNull(),
+				ResealOnKeyRenewal: types.BoolNull(),
+			},
+			ExpectedStale: types.BoolValue(false),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			state := tc.State
+			state.Stale = types.BoolNull()
+			diags := controllerResource(client).readKeyRenewal(context.Background(), &state)
+			assert.False(t, diags.HasError(), diags)
+			assert.Equal(t, tc.ExpectedWarning, diags.WarningsCount() == 1)
+			assert.Equal(t, tc.ExpectedStale, state.Stale)
+		})
+	}
+}
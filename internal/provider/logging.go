@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// maskSecretValues returns a context whose logs never show the plaintext values, neither in
+// messages, e.g. through a logged error, nor in fields. Fields named after the attributes holding
+// plaintext are masked whatever their value.
+func maskSecretValues(ctx context.Context, values []string) context.Context {
+	ctx = tflog.MaskFieldValuesWithFieldKeys(ctx, data, stringData, dataBase64, password)
+	return maskLogStrings(ctx, values)
+}
+
+// maskLogStrings returns a context whose logs never show values, empty values aside.
+func maskLogStrings(ctx context.Context, values []string) context.Context {
+	nonEmpty := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != "" {
+			nonEmpty = append(nonEmpty, v)
+		}
+	}
+	return tflog.MaskLogStrings(ctx, nonEmpty...)
+}
+
+// secretValues returns the plaintext values of the model known so far. The content of files is
+// only known once read, buildSecret masks it then.
+func (m *sealedSecretModel) secretValues() []string {
+	values := mapValues(m.Data, m.StringData, m.DataBase64)
+	for _, r := range m.DockerRegistries {
+		values = append(values, r.Password.ValueString())
+	}
+	return values
+}
+
+// bundleSecretValues returns the plaintext values of the entries of a bundle or kustomization.
+func bundleSecretValues(entries []sealedSecretBundleEntryModel) []string {
+	var values []string
+	for _, e := range entries {
+		values = append(values, mapValues(e.Data, e.StringData, e.DataBase64)...)
+	}
+	return values
+}
+
+// mapStringValues returns the values of m.
+func mapStringValues(m map[string]string) []string {
+	values := make([]string, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+	return values
+}
+
+// mapValues returns the known values of string maps.
+func mapValues(maps ...types.Map) []string {
+	var values []string
+	for _, m := range maps {
+		for _, v := range m.Elements() {
+			if s, ok := v.(types.String); ok && !s.IsNull() && !s.IsUnknown() {
+				values = append(values, s.ValueString())
+			}
+		}
+	}
+	return values
+}
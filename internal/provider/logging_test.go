@@ -0,0 +1,65 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	fpath "path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaskSecretValues(t *testing.T) {
+	var output bytes.Buffer
+	ctx := maskSecretValues(tflogtest.RootLogger(context.Background(), &output), []string{"valueAA", ""})
+
+	err := errors.New(`value of key "keyAA" is not valid: valueAA`)
+	tflog.Debug(ctx, "Failed with valueAA", map[string]interface{}{
+		"error": err.Error(),
+		data:    "valueBB",
+	})
+
+	entries, decodeErr := tflogtest.MultilineJSONDecode(&output)
+	assert.Nil(t, decodeErr)
+	assert.Equal(t, []map[string]interface{}{{
+		"@level":   "debug",
+		"@message": "Failed with ***",
+		"@module":  "provider",
+		"error":    `value of key "keyAA" is not valid: ***`,
+		data:       "***",
+	}}, entries)
+}
+
+func TestBuildSecretMasksFileValues(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"secret.env": "keyAA=valueAA\n", "fileBB": "valueBB"})
+	ctx := context.Background()
+	var plan sealedSecretModel
+	state := sealedSecretState(ctx, t, map[string]attr.Value{
+		name:       types.StringValue("name-aa"),
+		namespace:  types.StringValue("ns-aa"),
+		secretType: types.StringValue("Opaque"),
+		envFile:    types.StringValue(fpath.Join(dir, "secret.env")),
+		files: types.MapValueMust(types.StringType, map[string]attr.Value{
+			"keyBB": types.StringValue(fpath.Join(dir, "fileBB")),
+		}),
+	})
+	if diags := state.Get(ctx, &plan); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	var output bytes.Buffer
+	ctx, secret, _, err := buildSecret(tflogtest.RootLogger(ctx, &output), &plan)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("valueAA"), secret.Data["keyAA"])
+
+	tflog.Debug(ctx, "Sealed valueAA and valueBB")
+	assert.Contains(t, output.String(), "Sealed *** and ***")
+	assert.False(t, strings.Contains(output.String(), "valueAA") || strings.Contains(output.String(), "valueBB"))
+}
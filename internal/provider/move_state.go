@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// akselleirvProvider is the source of the provider this one descends from, as found at the end of
+// a provider address like registry.terraform.io/akselleirv/sealedsecret.
+const akselleirvProvider = "akselleirv/sealedsecret"
+
+// akselleirvSealedSecret is the state of the sealedsecret resource of akselleirvProvider. The sealed
+// manifest was committed to a git repository at filepath rather than kept in the state.
+type akselleirvSealedSecret struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"`
+	Type      string            `json:"type"`
+	Secrets   map[string]string `json:"secrets"`
+	Filepath  string            `json:"filepath"`
+}
+
+// MoveState converts the state of the sealedsecret resource of the akselleirv provider, so that
+// switching providers with a moved block keeps the secrets instead of replacing them.
+func (r *sealedSecretResource) MoveState(context.Context) []resource.StateMover {
+	return []resource.StateMover{
+		{StateMover: moveAkselleirvState},
+	}
+}
+
+// moveAkselleirvState maps secrets, which that provider base64 encoded itself, to data. Its git
+// fields and its public_key_hash, hashed differently than ours, have no equivalent and are
+// dropped. public_key and sealed_secret are left null so that the following apply seals the
+// secret with the key of the configuration.
+func moveAkselleirvState(ctx context.Context, req resource.MoveStateRequest, resp *resource.MoveStateResponse) {
+	if req.SourceTypeName != "sealedsecret" || !strings.HasSuffix(req.SourceProviderAddress, "/"+akselleirvProvider) {
+		return
+	}
+	if req.SourceRawState == nil || req.SourceRawState.JSON == nil {
+		resp.Diagnostics.AddError("Unable to move resource state", "The source state is empty or in the flatmap format of Terraform 0.11, which is not supported. Refresh it with a recent Terraform version first.")
+		return
+	}
+
+	var source akselleirvSealedSecret
+	if err := json.Unmarshal(req.SourceRawState.JSON, &source); err != nil {
+		resp.Diagnostics.AddError("Unable to move resource state", fmt.Sprintf("The state of %s is invalid: %s", akselleirvProvider, err))
+		return
+	}
+	tflog.Debug(ctx, "Move sealed secret state", map[string]interface{}{
+		"source_provider": req.SourceProviderAddress,
+		"name":            source.Name,
+		"namespace":       source.Namespace,
+	})
+
+	secretData, diags := types.MapValueFrom(ctx, types.StringType, source.Secrets)
+	resp.Diagnostics.Append(diags...)
+	if source.Type == "" {
+		source.Type = "Opaque"
+	}
+	resp.Diagnostics.Append(resp.TargetState.SetAttribute(ctx, path.Root(name), source.Name)...)
+	resp.Diagnostics.Append(resp.TargetState.SetAttribute(ctx, path.Root(namespace), source.Namespace)...)
+	resp.Diagnostics.Append(resp.TargetState.SetAttribute(ctx, path.Root(scope), defaultScope)...)
+	resp.Diagnostics.Append(resp.TargetState.SetAttribute(ctx, path.Root(secretType), source.Type)...)
+	resp.Diagnostics.Append(resp.TargetState.SetAttribute(ctx, path.Root(data), secretData)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if source.Filepath != "" {
+		resp.Diagnostics.AddWarning(
+			"Sealed secret no longer written to git",
+			fmt.Sprintf("%s/%s was committed to %s by %s. This provider only exposes the manifest as sealed_secret, write it to the repository with another resource to keep it up to date.",
+				source.Namespace, source.Name, source.Filepath, akselleirvProvider),
+		)
+	}
+}
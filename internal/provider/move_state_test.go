@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoveAkselleirvState(t *testing.T) {
+	ctx := context.Background()
+	movedState := func(typ string) map[string]attr.Value {
+		return map[string]attr.Value{
+			name:       types.StringValue("name-aa"),
+			namespace:  types.StringValue("ns-aa"),
+			scope:      types.StringValue(defaultScope),
+			secretType: types.StringValue(typ),
+			data: types.MapValueMust(types.StringType, map[string]attr.Value{
+				"keyAA": types.StringValue("dmFsdWVBQQ=="),
+			}),
+		}
+	}
+
+	type diagnostic struct {
+		Severity diag.Severity
+		Summary  string
+	}
+	tests := []struct {
+		Name                string
+		ProviderAddress     string
+		TypeName            string
+		RawState            *tfprotov6.RawState
+		ExpectedState       map[string]attr.Value
+		ExpectedDiagnostics []diagnostic
+	}{
+		{
+			Name:            "with filepath",
+			ProviderAddress: "registry.terraform.io/akselleirv/sealedsecret",
+			TypeName:        "sealedsecret",
+			RawState: &tfprotov6.RawState{JSON: []byte(`{
+				"name": "name-aa",
+				"namespace": "ns-aa",
+				"type": "kubernetes.io/basic-auth",
+				"secrets": {"keyAA": "dmFsdWVBQQ=="},
+				"filepath": "secrets/name-aa.yaml",
+				"public_key_hash": "hashAA"
+			}`)},
+			ExpectedState:       movedState("kubernetes.io/basic-auth"),
+			ExpectedDiagnostics: []diagnostic{{diag.SeverityWarning, "Sealed secret no longer written to git"}},
+		},
+		{
+			Name:            "without filepath",
+			ProviderAddress: "registry.terraform.io/akselleirv/sealedsecret",
+			TypeName:        "sealedsecret",
+			RawState: &tfprotov6.RawState{JSON: []byte(`{
+				"name": "name-aa",
+				"namespace": "ns-aa",
+				"secrets": {"keyAA": "dmFsdWVBQQ=="}
+			}`)},
+			ExpectedState: movedState("Opaque"),
+		},
+		{
+			Name:            "another provider",
+			ProviderAddress: "registry.terraform.io/other/sealedsecret",
+			TypeName:        "sealedsecret",
+			RawState:        &tfprotov6.RawState{JSON: []byte(`{"name": "name-aa"}`)},
+		},
+		{
+			Name:            "another resource type",
+			ProviderAddress: "registry.terraform.io/akselleirv/sealedsecret",
+			TypeName:        "sealedsecret_other",
+			RawState:        &tfprotov6.RawState{JSON: []byte(`{"name": "name-aa"}`)},
+		},
+		{
+			Name:                "flatmap state",
+			ProviderAddress:     "registry.terraform.io/akselleirv/sealedsecret",
+			TypeName:            "sealedsecret",
+			RawState:            &tfprotov6.RawState{Flatmap: map[string]string{"name": "name-aa"}},
+			ExpectedDiagnostics: []diagnostic{{diag.SeverityError, "Unable to move resource state"}},
+		},
+		{
+			Name:                "invalid state",
+			ProviderAddress:     "registry.terraform.io/akselleirv/sealedsecret",
+			TypeName:            "sealedsecret",
+			RawState:            &tfprotov6.RawState{JSON: []byte(`{"secrets": ["valueAA"]}`)},
+			ExpectedDiagnostics: []diagnostic{{diag.SeverityError, "Unable to move resource state"}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			resp := resource.MoveStateResponse{TargetState: sealedSecretState(ctx, t, nil)}
+			moveAkselleirvState(ctx, resource.MoveStateRequest{
+				SourceProviderAddress: tc.ProviderAddress,
+				SourceTypeName:        tc.TypeName,
+				SourceRawState:        tc.RawState,
+			}, &resp)
+
+			var got []diagnostic
+			for _, d := range resp.Diagnostics {
+				got = append(got, diagnostic{d.Severity(), d.Summary()})
+			}
+			assert.Equal(t, tc.ExpectedDiagnostics, got)
+			if len(resp.Diagnostics.Warnings()) > 0 {
+				assert.Contains(t, resp.Diagnostics.Warnings()[0].Detail(), "secrets/name-aa.yaml")
+			}
+			if tc.ExpectedState == nil {
+				assert.True(t, resp.TargetState.Raw.IsNull())
+				return
+			}
+			expected := sealedSecretState(ctx, t, tc.ExpectedState)
+			assert.True(t, expected.Raw.Equal(resp.TargetState.Raw), resp.TargetState.Raw.String())
+		})
+	}
+}
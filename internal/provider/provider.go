@@ -3,17 +3,22 @@ package provider
 import (
 	"context"
 
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/k8s"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
-	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/function"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
-	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 // Ensure the implementation satisfies the expected interfaces
 var (
-	_ provider.Provider = &sealedSecretProvider{}
+	_ provider.Provider              = &sealedSecretProvider{}
+	_ provider.ProviderWithFunctions = &sealedSecretProvider{}
 )
 
 // New is a helper function to simplify provider server and testing implementation.
@@ -21,26 +26,158 @@ func New() provider.Provider {
 	return &sealedSecretProvider{}
 }
 
+const (
+	kubernetes          = "kubernetes"
+	controllerName      = "controller_name"
+	controllerNamespace = "controller_namespace"
+)
+
 // hashicupsProvider is the provider implementation.
 type sealedSecretProvider struct{}
 
+type sealedSecretProviderModel struct {
+	Kubernetes          []kubernetesModel `tfsdk:"kubernetes"`
+	Clusters            []clusterModel    `tfsdk:"cluster"`
+	ControllerName      types.String      `tfsdk:"controller_name"`
+	ControllerNamespace types.String      `tfsdk:"controller_namespace"`
+}
+
+type kubernetesModel struct {
+	Host                 types.String `tfsdk:"host"`
+	ClusterCACertificate types.String `tfsdk:"cluster_ca_certificate"`
+	ClientCertificate    types.String `tfsdk:"client_certificate"`
+	ClientKey            types.String `tfsdk:"client_key"`
+	Token                types.String `tfsdk:"token"`
+}
+
+// providerData is handed to resources and data sources. Client is nil when the provider has no
+// kubernetes block.
+type providerData struct {
+	Client *k8s.Client
+	// ControllerName and ControllerNamespace are both empty when neither is configured, see
+	// controller.
+	ControllerName      string
+	ControllerNamespace string
+	// Clusters holds the PEM certificate of every cluster block, keyed by name.
+	Clusters map[string]string
+
+	controllerService controllerService
+	controllerCert    controllerCert
+}
+
 // Metadata returns the provider type name.
 func (p *sealedSecretProvider) Metadata(_ context.Context, _ provider.MetadataRequest, resp *provider.MetadataResponse) {
 	resp.TypeName = "sealedsecret"
 }
 
 // GetSchema defines the provider-level schema for configuration data.
-func (p *sealedSecretProvider) GetSchema(_ context.Context) (tfsdk.Schema, diag.Diagnostics) {
-	return tfsdk.Schema{
-		Description: "Interact with HashiCups.",
-	}, nil
+func (p *sealedSecretProvider) Schema(_ context.Context, _ provider.SchemaRequest, resp *provider.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Seal Kubernetes secrets for the sealed-secrets controller.",
+		Attributes: map[string]schema.Attribute{
+			controllerName: schema.StringAttribute{
+				Optional:    true,
+				Description: "Name of the sealed-secrets controller service, defaults to " + k8s.DefaultControllerName + ". When neither this nor controller_namespace is set, the service is discovered across namespaces by the labels of the sealed-secrets manifests and Helm chart",
+			},
+			controllerNamespace: schema.StringAttribute{
+				Optional:    true,
+				Description: "Namespace of the sealed-secrets controller service, defaults to " + k8s.DefaultControllerNamespace + ". When neither this nor controller_name is set, the service is discovered like for controller_name",
+			},
+		},
+		Blocks: map[string]schema.Block{
+			kubernetes: schema.ListNestedBlock{
+				Description: "Connection to the Kubernetes cluster, only needed by resources reading from the cluster",
+				Validators: []validator.List{
+					listvalidator.SizeAtMost(1),
+				},
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"host": schema.StringAttribute{
+							Required:    true,
+							Description: "The hostname (in form of URI) of the Kubernetes API",
+						},
+						"cluster_ca_certificate": schema.StringAttribute{
+							Optional:    true,
+							Description: "PEM-encoded root certificates bundle for TLS authentication",
+						},
+						"client_certificate": schema.StringAttribute{
+							Optional:    true,
+							Description: "PEM-encoded client certificate for TLS authentication",
+						},
+						"client_key": schema.StringAttribute{
+							Optional:    true,
+							Sensitive:   true,
+							Description: "PEM-encoded client certificate key for TLS authentication",
+						},
+						"token": schema.StringAttribute{
+							Optional:    true,
+							Sensitive:   true,
+							Description: "Bearer token for authentication to the Kubernetes API",
+						},
+					},
+				},
+			},
+			clusters: clusterBlock(),
+		},
+	}
 }
 
-// Configure prepares a HashiCups API client for data sources and resources.
+// Configure prepares the Kubernetes client for data sources and resources.
 func (p *sealedSecretProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
-	tflog.Info(ctx, "Configuring HashiCups client")
+	tflog.Debug(ctx, "Configuring sealedsecret provider")
+	var config sealedSecretProviderModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	tflog.Info(ctx, "Configured HashiCups client", map[string]any{"success": true})
+	data := &providerData{}
+	// without either, the controller is discovered by the labels of its service
+	if !config.ControllerName.IsNull() || !config.ControllerNamespace.IsNull() {
+		data.ControllerName, data.ControllerNamespace = k8s.DefaultControllerName, k8s.DefaultControllerNamespace
+		if !config.ControllerName.IsNull() {
+			data.ControllerName = config.ControllerName.ValueString()
+		}
+		if !config.ControllerNamespace.IsNull() {
+			data.ControllerNamespace = config.ControllerNamespace.ValueString()
+		}
+	}
+
+	if len(config.Kubernetes) > 0 {
+		kc := config.Kubernetes[0]
+		if kc.Host.IsUnknown() || kc.ClusterCACertificate.IsUnknown() || kc.ClientCertificate.IsUnknown() ||
+			kc.ClientKey.IsUnknown() || kc.Token.IsUnknown() {
+			resp.Diagnostics.AddError(
+				"Unknown Kubernetes configuration",
+				"The kubernetes block depends on values only known after apply, the provider cannot connect to the cluster.",
+			)
+			return
+		}
+
+		client, err := k8s.NewClient(&k8s.Config{
+			Host:          kc.Host.ValueString(),
+			ClusterCACert: []byte(kc.ClusterCACertificate.ValueString()),
+			ClientCert:    []byte(kc.ClientCertificate.ValueString()),
+			ClientKey:     []byte(kc.ClientKey.ValueString()),
+			Token:         kc.Token.ValueString(),
+		})
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to create Kubernetes client", err.Error())
+			return
+		}
+		data.Client = client
+	}
+
+	resp.Diagnostics.Append(loadClusters(ctx, config.Clusters, data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.DataSourceData = data
+	resp.ResourceData = data
+
+	tflog.Debug(ctx, "Configured sealedsecret provider", map[string]any{"kubernetes": data.Client != nil, "clusters": len(data.Clusters)})
 }
 
 // DataSources defines the data sources implemented in the provider.
@@ -52,5 +189,18 @@ func (p *sealedSecretProvider) DataSources(_ context.Context) []func() datasourc
 func (p *sealedSecretProvider) Resources(_ context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewSealedSecretResource,
+		NewSealedSecretMergeResource,
+		NewSealedSecretBundleResource,
+		NewSealedSecretKustomizationResource,
+		NewSealedSecretFromClusterResource,
+	}
+}
+
+// Functions defines the provider-defined functions, available from Terraform 1.8.
+func (p *sealedSecretProvider) Functions(_ context.Context) []func() function.Function {
+	return []func() function.Function{
+		NewSealValueFunction,
+		NewFingerprintFunction,
+		NewParseSealedFunction,
 	}
 }
@@ -0,0 +1,214 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/fakecontroller"
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/k8s"
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func testClient(t *testing.T, mock roundTripFunc) *k8s.Client {
+	client, err := k8s.NewClient(&k8s.Config{Host: "https://kubernetes", Transport: mock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func jsonResponse(t *testing.T, code int, v interface{}) *http.Response {
+	body, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &http.Response{
+		StatusCode: code,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(string(body))),
+	}
+}
+
+func statusResponse(t *testing.T, code int32, reason metav1.StatusReason) *http.Response {
+	return jsonResponse(t, int(code), metav1.Status{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Status"},
+		Status:   metav1.StatusFailure,
+		Code:     code,
+		Reason:   reason,
+	})
+}
+
+func testCertPEM(t *testing.T) string {
+	_, cert, err := crypto.GeneratePrivateKeyAndCert(2048, time.Hour, "sealed-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+}
+
+// objectValue returns an object of type typ with the given attributes, every other one null.
+func objectValue(typ tftypes.Type, attrs map[string]tftypes.Value) tftypes.Value {
+	values := map[string]tftypes.Value{}
+	for k, attrType := range typ.(tftypes.Object).AttributeTypes {
+		values[k] = tftypes.NewValue(attrType, nil)
+		if v, ok := attrs[k]; ok {
+			values[k] = v
+		}
+	}
+	return tftypes.NewValue(typ, values)
+}
+
+func dynamicValue(t *testing.T, v tftypes.Value) *tfprotov6.DynamicValue {
+	dv, err := tfprotov6.NewDynamicValue(v.Type(), v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &dv
+}
+
+func assertNoDiagnostics(t *testing.T, diags []*tfprotov6.Diagnostic) {
+	t.Helper()
+	for _, d := range diags {
+		t.Errorf("%s: %s", d.Summary, d.Detail)
+	}
+	if t.Failed() {
+		t.FailNow()
+	}
+}
+
+// TestProviderSealsWithController creates a sealedsecret resource through the provider server,
+// sealing with the certificate of a cluster fetched from the controller of the kubernetes block.
+func TestProviderSealsWithController(t *testing.T) {
+	ctx := context.Background()
+	controller, err := fakecontroller.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer controller.Close()
+	server := providerserver.NewProtocol6(New())()
+
+	schemas, err := server.GetProviderSchema(ctx, &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertNoDiagnostics(t, schemas.Diagnostics)
+	providerType := schemas.Provider.ValueType()
+	kubernetesType := providerType.(tftypes.Object).AttributeTypes[kubernetes].(tftypes.List).ElementType
+	clusterType := providerType.(tftypes.Object).AttributeTypes[clusters].(tftypes.List).ElementType
+	providerConfig := objectValue(providerType, map[string]tftypes.Value{
+		kubernetes: tftypes.NewValue(tftypes.List{ElementType: kubernetesType}, []tftypes.Value{
+			objectValue(kubernetesType, map[string]tftypes.Value{
+				"host": tftypes.NewValue(tftypes.String, controller.URL()),
+			}),
+		}),
+		clusters: tftypes.NewValue(tftypes.List{ElementType: clusterType}, []tftypes.Value{
+			objectValue(clusterType, map[string]tftypes.Value{
+				name:                tftypes.NewValue(tftypes.String, "clusterAA"),
+				fetchFromController: tftypes.NewValue(tftypes.Bool, true),
+			}),
+		}),
+	})
+	configured, err := server.ConfigureProvider(ctx, &tfprotov6.ConfigureProviderRequest{Config: dynamicValue(t, providerConfig)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertNoDiagnostics(t, configured.Diagnostics)
+
+	resourceType := schemas.ResourceSchemas["sealedsecret"].ValueType()
+	dataType := resourceType.(tftypes.Object).AttributeTypes[data]
+	config := objectValue(resourceType, map[string]tftypes.Value{
+		name:      tftypes.NewValue(tftypes.String, "name-aa"),
+		namespace: tftypes.NewValue(tftypes.String, "ns-aa"),
+		clusters:  tftypes.NewValue(tftypes.String, "clusterAA"),
+		data: tftypes.NewValue(dataType, map[string]tftypes.Value{
+			"keyAA": tftypes.NewValue(tftypes.String, "valueAA"),
+		}),
+	})
+	validated, err := server.ValidateResourceConfig(ctx, &tfprotov6.ValidateResourceConfigRequest{
+		TypeName: "sealedsecret",
+		Config:   dynamicValue(t, config),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertNoDiagnostics(t, validated.Diagnostics)
+
+	planResourceChange := func(prior, proposed *tfprotov6.DynamicValue, private []byte) *tfprotov6.PlanResourceChangeResponse {
+		planned, err := server.PlanResourceChange(ctx, &tfprotov6.PlanResourceChangeRequest{
+			TypeName:         "sealedsecret",
+			PriorState:       prior,
+			ProposedNewState: proposed,
+			Config:           dynamicValue(t, config),
+			PriorPrivate:     private,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertNoDiagnostics(t, planned.Diagnostics)
+		return planned
+	}
+
+	// Terraform plans again during the apply, both plans must agree
+	priorState := dynamicValue(t, tftypes.NewValue(resourceType, nil))
+	planned := planResourceChange(priorState, dynamicValue(t, config), nil)
+	assert.Equal(t, planned.PlannedState, planResourceChange(priorState, dynamicValue(t, config), nil).PlannedState)
+
+	applied, err := server.ApplyResourceChange(ctx, &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:       "sealedsecret",
+		PriorState:     priorState,
+		PlannedState:   planned.PlannedState,
+		Config:         dynamicValue(t, config),
+		PlannedPrivate: planned.PlannedPrivate,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertNoDiagnostics(t, applied.Diagnostics)
+
+	state, err := applied.NewState.Unmarshal(resourceType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var attrs map[string]tftypes.Value
+	if err := state.As(&attrs); err != nil {
+		t.Fatal(err)
+	}
+	var sealedSecret string
+	if err := attrs["sealed_secret"].As(&sealedSecret); err != nil {
+		t.Fatal(err)
+	}
+	secret, err := controller.Unseal([]byte(sealedSecret))
+	assert.Nil(t, err)
+	assert.Equal(t, "name-aa", secret.Name)
+	assert.Equal(t, "ns-aa", secret.Namespace)
+	assert.Equal(t, []byte("valueAA"), secret.Data["keyAA"])
+
+	// planning the applied configuration again changes nothing
+	replanned := planResourceChange(applied.NewState, applied.NewState, applied.Private)
+	assert.Equal(t, applied.NewState, replanned.PlannedState)
+	assert.Empty(t, replanned.RequiresReplace)
+
+	// a state without salt, e.g. moved or written before key_digests, plans the same twice too
+	attrs[keyDigestSalt] = tftypes.NewValue(tftypes.String, nil)
+	attrs[keyDigests] = tftypes.NewValue(attrs[keyDigests].Type(), nil)
+	unsalted := dynamicValue(t, tftypes.NewValue(resourceType, attrs))
+	replanned = planResourceChange(unsalted, unsalted, nil)
+	assert.Equal(t, replanned.PlannedState, planResourceChange(unsalted, unsalted, nil).PlannedState)
+}
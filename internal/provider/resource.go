@@ -2,33 +2,75 @@ package provider
 
 import (
 	"context"
-	"crypto/rsa"
-	"crypto/x509"
-	"encoding/pem"
+	"encoding/base64"
 	"fmt"
+	"strconv"
 
 	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/k8s"
 	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/kubeseal"
 	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/provider/attribute_plan_modifier"
+	"github.com/hashicorp/terraform-plugin-framework-validators/boolvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
+	v1 "k8s.io/api/core/v1"
 )
 
 const (
-	name          = "name"
-	scope         = "scope"
-	namespace     = "namespace"
-	secretType    = "type"
-	data          = "data"
-	stringData    = "string_data"
-	filepath      = "filepath"
-	publicKeyHash = "public_key_hash"
+	name                    = "name"
+	scope                   = "scope"
+	namespace               = "namespace"
+	secretType              = "type"
+	data                    = "data"
+	stringData              = "string_data"
+	dataBase64              = "data_base64"
+	envFile                 = "env_file"
+	jsonFile                = "json_file"
+	yamlFile                = "yaml_file"
+	dataFilesSHA256         = "data_files_sha256"
+	filesSHA256             = "files_sha256"
+	immutable               = "immutable"
+	sealedSecretLabels      = "sealedsecret_labels"
+	sealedSecretAnnotations = "sealedsecret_annotations"
+	managed                 = "managed"
+	patch                   = "patch"
+	skipSetOwnerReferences  = "skip_set_owner_references"
+	labels                  = "labels"
+	annotations             = "annotations"
+	dockerRegistries        = "docker_registries"
+	filepath                = "filepath"
+	publicKeyHash           = "public_key_hash"
 )
+
+// defaultScope is the scope of a sealed secret when none is given, like kubeseal.
+const defaultScope = "strict"
+
+// scopeValidator accepts the scopes of the controller.
+func scopeValidator() validator.String {
+	return stringvalidator.OneOf(defaultScope, "namespace-wide", "cluster-wide")
+}
+
+// Annotations read by the sealed-secrets controller from the unsealed secret
 const (
+	managedAnnotation                = "sealedsecrets.bitnami.com/managed"
+	patchAnnotation                  = "sealedsecrets.bitnami.com/patch"
+	skipSetOwnerReferencesAnnotation = "sealedsecrets.bitnami.com/skip-set-owner-references"
+)
+const (
+	server       = "server"
 	username     = "username"
+	password     = "password"
+	email        = "email"
 	token        = "token"
 	url          = "url"
 	sourceBranch = "source_branch"
@@ -48,17 +90,49 @@ type SealedSecret struct {
 	} `yaml:"spec"`
 }
 
-type sealedSecretResource struct{}
+type sealedSecretResource struct {
+	provider *providerData
+}
 
 type sealedSecretModel struct {
-	Name         types.String `tfsdk:"name"`
-	Namespace    types.String `tfsdk:"namespace"`
-	Scope        types.String `tfsdk:"scope"`
-	SecretType   types.String `tfsdk:"type"`
-	StringData   types.Map    `tfsdk:"string_data"`
-	Data         types.Map    `tfsdk:"data"`
-	PublicKey    types.String `tfsdk:"public_key"`
-	SealedSecret types.String `tfsdk:"sealed_secret"`
+	Name                    types.String          `tfsdk:"name"`
+	Namespace               types.String          `tfsdk:"namespace"`
+	Scope                   types.String          `tfsdk:"scope"`
+	SecretType              types.String          `tfsdk:"type"`
+	StringData              types.Map             `tfsdk:"string_data"`
+	Data                    types.Map             `tfsdk:"data"`
+	DataBase64              types.Map             `tfsdk:"data_base64"`
+	EnvFile                 types.String          `tfsdk:"env_file"`
+	JSONFile                types.String          `tfsdk:"json_file"`
+	YAMLFile                types.String          `tfsdk:"yaml_file"`
+	DataFilesSHA256         types.Map             `tfsdk:"data_files_sha256"`
+	Files                   types.Map             `tfsdk:"files"`
+	FilesSHA256             types.Map             `tfsdk:"files_sha256"`
+	Immutable               types.Bool            `tfsdk:"immutable"`
+	SealedSecretLabels      types.Map             `tfsdk:"sealedsecret_labels"`
+	SealedSecretAnnotations types.Map             `tfsdk:"sealedsecret_annotations"`
+	Managed                 types.Bool            `tfsdk:"managed"`
+	Patch                   types.Bool            `tfsdk:"patch"`
+	SkipSetOwnerReferences  types.Bool            `tfsdk:"skip_set_owner_references"`
+	Labels                  types.Map             `tfsdk:"labels"`
+	Annotations             types.Map             `tfsdk:"annotations"`
+	DockerRegistries        []dockerRegistryModel `tfsdk:"docker_registries"`
+	PublicKey               types.String          `tfsdk:"public_key"`
+	Cluster                 types.String          `tfsdk:"cluster"`
+	PublicKeyHash           types.String          `tfsdk:"public_key_hash"`
+	ResealOnKeyRenewal      types.Bool            `tfsdk:"reseal_on_key_renewal"`
+	Stale                   types.Bool            `tfsdk:"stale"`
+	Keys                    types.List            `tfsdk:"keys"`
+	KeyDigests              types.Map             `tfsdk:"key_digests"`
+	KeyDigestSalt           types.String          `tfsdk:"key_digest_salt"`
+	SealedSecret            types.String          `tfsdk:"sealed_secret"`
+}
+
+type dockerRegistryModel struct {
+	Server   types.String `tfsdk:"server"`
+	Username types.String `tfsdk:"username"`
+	Password types.String `tfsdk:"password"`
+	Email    types.String `tfsdk:"email"`
 }
 
 func NewSealedSecretResource() resource.Resource {
@@ -69,178 +143,653 @@ func (r *sealedSecretResource) Metadata(_ context.Context, req resource.Metadata
 	resp.TypeName = "sealedsecret"
 }
 
-func (r *sealedSecretResource) GetSchema(_ context.Context) (tfsdk.Schema, diag.Diagnostics) {
-	return tfsdk.Schema{
-		Attributes: map[string]tfsdk.Attribute{
-			name: {
-				Type:        types.StringType,
+func (r *sealedSecretResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	publicKeyAttr, clusterAttr := publicKeyAttributes("The public key used to seal the secret.")
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			name: schema.StringAttribute{
 				Required:    true,
 				Description: "name of the secret, must be unique",
 			},
-			scope: {
-				Type:        types.StringType,
-				Optional:    true,
-				Description: "Set the scope of the sealed secret: strict, namespace-wide, cluster-wide",
+			scope: schema.StringAttribute{
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString(defaultScope),
+				Validators: []validator.String{
+					scopeValidator(),
+				},
+				Description: "Set the scope of the sealed secret: strict, namespace-wide, cluster-wide. Defaults to strict",
 			},
-			namespace: {
-				Type:        types.StringType,
+			namespace: schema.StringAttribute{
 				Required:    true,
 				Description: "namespace of the secret",
 			},
-			secretType: {
-				Type:     types.StringType,
+			secretType: schema.StringAttribute{
 				Computed: true,
 				Optional: true,
-				PlanModifiers: []tfsdk.AttributePlanModifier{
-					resource.RequiresReplace(),
-					attribute_plan_modifier.DefaultValue(types.String{Value: "Opaque"}),
+				PlanModifiers: []planmodifier.String{
+					attribute_plan_modifier.DefaultValueIfConfigured(path.Root(dockerRegistries), types.StringValue("kubernetes.io/dockerconfigjson")),
+					attribute_plan_modifier.DefaultValue(types.StringValue("Opaque")),
+					stringplanmodifier.RequiresReplace(),
 				},
-				Description: "The secret type (ex. Opaque)",
+				Description: "The secret type (ex. Opaque), defaults to kubernetes.io/dockerconfigjson when docker_registries is set. Well-known types (kubernetes.io/tls, kubernetes.io/basic-auth, kubernetes.io/ssh-auth, kubernetes.io/service-account-token, kubernetes.io/dockercfg, kubernetes.io/dockerconfigjson) are checked for their required keys before sealing",
 			},
-			data: {
-				Type: types.MapType{
-					ElemType: types.StringType,
-				},
+			labels: schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Labels to set on the secret",
+			},
+			annotations: schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Annotations to set on the secret (ex. kubernetes.io/service-account.name for service account tokens)",
+			},
+			sealedSecretLabels: schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Labels to set on the SealedSecret object itself, not on the unsealed secret",
+			},
+			sealedSecretAnnotations: schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Annotations to set on the SealedSecret object itself, not on the unsealed secret (ex. argocd.argoproj.io/sync-wave)",
+			},
+			data: schema.MapAttribute{
+				ElementType: types.StringType,
 				Optional:    true,
 				Sensitive:   true,
+				PlanModifiers: []planmodifier.Map{
+					attribute_plan_modifier.RequiresReplaceIfTrue(path.Root(immutable)),
+				},
 				Description: "Key/value pairs to populate the secret. The value will be base64 encoded",
 			},
-			stringData: {
-				Type: types.MapType{
-					ElemType: types.StringType,
-				},
+			stringData: schema.MapAttribute{
+				ElementType: types.StringType,
 				Optional:    true,
 				Sensitive:   true,
+				PlanModifiers: []planmodifier.Map{
+					attribute_plan_modifier.RequiresReplaceIfTrue(path.Root(immutable)),
+				},
 				Description: "Key/value pairs to populate the secret.",
 			},
+			dataBase64: schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Sensitive:   true,
+				PlanModifiers: []planmodifier.Map{
+					attribute_plan_modifier.RequiresReplaceIfTrue(path.Root(immutable)),
+				},
+				Description: "Key/value pairs to populate the secret. The value must already be base64 encoded and is passed through untouched, use this for binary content",
+			},
+			envFile: schema.StringAttribute{
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					attribute_plan_modifier.RequiresReplaceIfTrue(path.Root(immutable)),
+				},
+				Description: "Path of a dotenv file to populate the secret from. Supports export prefixes, comments, single and double quoted values and multi-line quoted values. Keys also set in string_data take the string_data value",
+			},
+			jsonFile: schema.StringAttribute{
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					attribute_plan_modifier.RequiresReplaceIfTrue(path.Root(immutable)),
+				},
+				Description: "Path of a JSON file holding an object of keys to string, number or boolean values to populate the secret from. Keys also set in string_data take the string_data value",
+			},
+			yamlFile: schema.StringAttribute{
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					attribute_plan_modifier.RequiresReplaceIfTrue(path.Root(immutable)),
+				},
+				Description: "Path of a YAML file holding a mapping of keys to string, number or boolean values to populate the secret from. Keys also set in string_data take the string_data value",
+			},
+			dataFilesSHA256: schema.MapAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "SHA-256 of the content of env_file, json_file and yaml_file, keyed by attribute name. The secret is sealed again when a file's content changes",
+			},
+			files: schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				PlanModifiers: []planmodifier.Map{
+					attribute_plan_modifier.RequiresReplaceIfTrue(path.Root(immutable)),
+				},
+				Description: "Key/path pairs to populate the secret, like kubectl create secret --from-file. Files are read as raw bytes so binary content is safe, only their SHA-256 is stored in the state",
+			},
+			filesSHA256: schema.MapAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "SHA-256 of the content of every file of files, keyed by secret key. The secret is sealed again when a file's content changes",
+			},
 
-			"public_key": {
-				Type:        types.StringType,
-				Required:    true,
-				Sensitive:   false,
-				Description: "The public key hashed to detect if the public key changes.",
+			immutable: schema.BoolAttribute{
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
+				Description: "Mark the secret as immutable. Changing the data of an immutable secret forces replacement",
+			},
+
+			managed: schema.BoolAttribute{
+				Optional:    true,
+				Description: "Let the controller take over an existing secret of the same name. The existing secret must also carry the sealedsecrets.bitnami.com/managed annotation",
+			},
+			patch: schema.BoolAttribute{
+				Optional:    true,
+				Description: "Let the controller patch an existing secret of the same name instead of overwriting it. The existing secret must also carry the sealedsecrets.bitnami.com/patch annotation",
+			},
+			skipSetOwnerReferences: schema.BoolAttribute{
+				Optional:    true,
+				Description: "Keep the controller from setting the SealedSecret as owner of the unsealed secret, so deleting the SealedSecret leaves the secret in place",
+			},
+
+			publicKey: publicKeyAttr,
+			clusters:  clusterAttr,
+			publicKeyHash: schema.StringAttribute{
+				Computed:    true,
+				Description: "SHA-256 fingerprint of the public key. It only changes with the key itself, not with its PEM formatting, and a change seals the secret again",
+			},
+			resealOnKeyRenewal: schema.BoolAttribute{
+				Optional: true,
+				Validators: []validator.Bool{
+					boolvalidator.ConflictsWith(path.MatchRoot(publicKey)),
+				},
+				Description: "Seal with the current certificate of the controller instead of the one of cluster once the controller renewed its key. " +
+					"Requires the kubernetes block of the provider",
+			},
+			stale: schema.BoolAttribute{
+				Computed:    true,
+				Description: "Whether the controller renewed its key since the secret was sealed, checked at refresh when the kubernetes block of the provider is configured",
+			},
+			secretKeys: schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "Sorted keys of the secret, shown in plans unlike the sensitive values",
+			},
+			keyDigests: schema.MapAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "HMAC-SHA256 of every value of the secret keyed by key_digest_salt, showing in plans which values changed without revealing them",
+			},
+			keyDigestSalt: schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Random salt of key_digests, kept for the lifetime of the resource",
 			},
-			"sealed_secret": {
-				Type:        types.StringType,
+			"sealed_secret": schema.StringAttribute{
 				Computed:    true,
 				Sensitive:   false,
-				Description: "The public key hashed to detect if the public key changes.",
+				Description: "The sealed secret manifest, kept until an input of the ciphertext changes",
 			},
 		},
-	}, nil
+		Blocks: map[string]schema.Block{
+			dockerRegistries: schema.ListNestedBlock{
+				PlanModifiers: []planmodifier.List{
+					attribute_plan_modifier.RequiresReplaceIfTrue(path.Root(immutable)),
+				},
+				Description: "Docker registry credentials, rendered into the .dockerconfigjson key of a kubernetes.io/dockerconfigjson secret",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						server: schema.StringAttribute{
+							Required:    true,
+							Description: "Registry server (ex. ghcr.io)",
+						},
+						username: schema.StringAttribute{
+							Optional:    true,
+							Description: "Registry username",
+						},
+						password: schema.StringAttribute{
+							Optional:    true,
+							Sensitive:   true,
+							Description: "Registry password or token",
+						},
+						email: schema.StringAttribute{
+							Optional:    true,
+							Description: "Registry email",
+						},
+					},
+				},
+			},
+		},
+	}
 }
 
 func tfMaptoMapStringString(tfMap types.Map) (map[string]string, error) {
 	m := make(map[string]string)
-	for k, v := range tfMap.Elems {
-		m[k] = v.(types.String).Value
+	for k, v := range tfMap.Elements() {
+		m[k] = v.(types.String).ValueString()
 	}
 	return m, nil
 }
 
+func (r *sealedSecretResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.provider = resourceProviderData(req, resp)
+}
+
 func (r *sealedSecretResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
-	tflog.Error(ctx, "Create sealed secret resource")
+	tflog.Debug(ctx, "Create sealed secret resource")
 	var plan sealedSecretModel
 
-	diags := req.Plan.Get(ctx, &plan)
-
-	resp.Diagnostics.Append(diags...)
-
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	ctx = maskSecretValues(ctx, plan.secretValues())
 
-	data, err := tfMaptoMapStringString(plan.Data)
+	sealedSecret, err := createSealedSecret(ctx, &plan)
 	if err != nil {
-		diags.AddError("Failed to convert data tf map to map[string]string", err.Error())
+		resp.Diagnostics.AddError("Failed to seal secret", err.Error())
 		return
 	}
-	stringData, err := tfMaptoMapStringString(plan.StringData)
-	if err != nil {
-		diags.AddError("Failed to convert stringdata tf map to map[string]string", err.Error())
+
+	plan.SealedSecret = types.StringValue(string(sealedSecret))
+	plan.Stale = r.isStale(ctx, plan.PublicKeyHash)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *sealedSecretResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	tflog.Debug(ctx, "Read sealed secret resource")
+	if r.provider == nil || r.provider.Client == nil {
 		return
 	}
 
-	scope := plan.Scope.Value
-	if scope == "" {
-		scope = "strict"
+	var state sealedSecretModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	sealedSecret, err := createSealedSecret(ctx, plan.Name.Value, plan.Namespace.Value, scope, plan.SecretType.Value, plan.PublicKey.Value, data, stringData)
-	if err != nil {
-		diags.AddError("Failed to seal secret", err.Error())
+	resp.Diagnostics.Append(r.readKeyRenewal(ctx, &state)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+func (r *sealedSecretResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	tflog.Debug(ctx, "Update sealed secret resource")
+	var plan sealedSecretModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
+	ctx = maskSecretValues(ctx, plan.secretValues())
 
-	plan.SealedSecret = types.String{Value: string(sealedSecret)}
+	// ModifyPlan keeps sealed_secret when no input of the ciphertext changed
+	if plan.SealedSecret.IsUnknown() {
+		sealedSecret, err := createSealedSecret(ctx, &plan)
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to seal secret", err.Error())
+			return
+		}
+
+		plan.SealedSecret = types.StringValue(string(sealedSecret))
+	}
+	if plan.Stale.IsUnknown() {
+		plan.Stale = r.isStale(ctx, plan.PublicKeyHash)
+	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
 }
 
-func (r *sealedSecretResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
-	tflog.Error(ctx, "Read sealed secret resource")
-	return
+// ModifyPlan re-seals the secret when an input of the ciphertext changed, including the certificate
+// of its cluster, the key of the controller with reseal_on_key_renewal and the content of a file of
+// files, which the configuration alone does not show. Otherwise sealed_secret is kept.
+func (r *sealedSecretResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	var config sealedSecretModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx = maskSecretValues(ctx, config.secretValues())
+
+	modifyPlanPublicKey(ctx, r.provider, req, resp, path.Root("sealed_secret"))
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	r.modifyPlanKeyRenewal(ctx, req, resp)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	r.modifyPlanFiles(ctx, req, resp)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	r.modifyPlanSecret(ctx, req, resp)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	r.modifyPlanPublicKeyHash(ctx, req, resp)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	r.modifyPlanSealedSecret(ctx, req, resp)
 }
 
-func (r *sealedSecretResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	tflog.Error(ctx, "Update sealed secret resource")
+// modifyPlanFiles plans files_sha256 and data_files_sha256 from the current content of the files.
+// Files which cannot be read yet, e.g. because they are created by the same apply, leave the hashes
+// unknown. A change of content replaces an immutable secret.
+func (r *sealedSecretResource) modifyPlanFiles(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
 	var plan sealedSecretModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	diags := req.Plan.Get(ctx, &plan)
+	hashes := types.MapUnknown(types.StringType)
+	if !plan.Files.IsUnknown() {
+		if _, fileHashes, err := readFiles(plan.Files); err == nil {
+			hashes = fileHashesToMap(fileHashes)
+		} else {
+			tflog.Debug(ctx, "Files cannot be read at plan time", map[string]interface{}{"error": err.Error()})
+		}
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(filesSHA256), hashes)...)
+
+	dataFileHashes := types.MapUnknown(types.StringType)
+	if !plan.EnvFile.IsUnknown() && !plan.JSONFile.IsUnknown() && !plan.YAMLFile.IsUnknown() {
+		if fileHashes, err := hashDataFiles(&plan); err == nil {
+			dataFileHashes = fileHashesToMap(fileHashes)
+		} else {
+			tflog.Debug(ctx, "Data files cannot be read at plan time", map[string]interface{}{"error": err.Error()})
+		}
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(dataFilesSHA256), dataFileHashes)...)
 
-	resp.Diagnostics.Append(diags...)
+	if req.State.Raw.IsNull() {
+		return
+	}
+	var state sealedSecretModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() || !state.Immutable.ValueBool() {
+		return
+	}
+	if !hashes.Equal(state.FilesSHA256) {
+		resp.RequiresReplace = append(resp.RequiresReplace, path.Root(filesSHA256))
+	}
+	// states written before data_files_sha256 existed cannot tell whether the content changed
+	if !state.DataFilesSHA256.IsNull() && !dataFileHashes.Equal(state.DataFilesSHA256) {
+		resp.RequiresReplace = append(resp.RequiresReplace, path.Root(dataFilesSHA256))
+	}
+}
 
+// modifyPlanPublicKeyHash plans public_key_hash from the key material of public_key, warning with
+// both fingerprints when the key changed.
+func (r *sealedSecretResource) modifyPlanPublicKeyHash(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	var plan sealedSecretModel
+	resp.Diagnostics.Append(resp.Plan.Get(ctx, &plan)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	data, err := tfMaptoMapStringString(plan.Data)
-	if err != nil {
-		diags.AddError("Failed to convert data tf map to map[string]string", err.Error())
+	plan.PublicKeyHash = types.StringUnknown()
+	if !plan.PublicKey.IsUnknown() {
+		fingerprint, err := publicKeyFingerprint(plan.PublicKey.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root(publicKey), "Invalid public key", err.Error())
+			return
+		}
+		plan.PublicKeyHash = types.StringValue(fingerprint)
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(publicKeyHash), plan.PublicKeyHash)...)
+
+	if req.State.Raw.IsNull() || plan.PublicKeyHash.IsUnknown() {
 		return
 	}
-	stringData, err := tfMaptoMapStringString(plan.StringData)
-	if err != nil {
-		diags.AddError("Failed to convert stringdata tf map to map[string]string", err.Error())
+	var state sealedSecretModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	scope := plan.Scope.Value
-	if scope == "" {
-		scope = "strict"
+	priorHash := priorPublicKeyHash(&state)
+	// a state moved from another provider was never sealed by this one
+	if !priorHash.IsNull() && !plan.PublicKeyHash.Equal(priorHash) {
+		resp.Diagnostics.AddAttributeWarning(
+			path.Root(publicKey),
+			"Public key changed",
+			fmt.Sprintf("%s/%s is sealed again with the new key: %s -> %s",
+				plan.Namespace.ValueString(), plan.Name.ValueString(), priorHash.ValueString(), plan.PublicKeyHash.ValueString()),
+		)
 	}
+}
 
-	sealedSecret, err := createSealedSecret(ctx, plan.Name.Value, plan.Namespace.Value, scope, plan.SecretType.Value, plan.PublicKey.Value, data, stringData)
-	if err != nil {
-		diags.AddError("Failed to seal secret", err.Error())
-		return
+// modifyPlanSealedSecret keeps sealed_secret from the state when nothing the ciphertext depends on
+// changed, e.g. when only the formatting of public_key or the cluster it is taken from did, and
+// plans it unknown otherwise. stale is kept or planned unknown along with it.
+func (r *sealedSecretResource) modifyPlanSealedSecret(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	sealedSecret, isStale := types.StringUnknown(), types.BoolUnknown()
+
+	if !req.State.Raw.IsNull() {
+		var plan, state sealedSecretModel
+		resp.Diagnostics.Append(resp.Plan.Get(ctx, &plan)...)
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		// states written before public_key_hash, data_files_sha256 and the scope default existed
+		state.PublicKeyHash = priorPublicKeyHash(&state)
+		if state.Scope.IsNull() {
+			state.Scope = types.StringValue(defaultScope)
+		}
+		if state.DataFilesSHA256.IsNull() {
+			state.DataFilesSHA256 = plan.DataFilesSHA256
+		}
+
+		candidate := plan
+		// the key is compared by public_key_hash, the rest is derived from the inputs or does not
+		// reach the manifest
+		candidate.PublicKey = state.PublicKey
+		candidate.Cluster = state.Cluster
+		candidate.ResealOnKeyRenewal = state.ResealOnKeyRenewal
+		candidate.Keys = state.Keys
+		candidate.KeyDigests = state.KeyDigests
+		candidate.KeyDigestSalt = state.KeyDigestSalt
+		candidate.Stale = state.Stale
+		candidate.SealedSecret = state.SealedSecret
+		same, diags := sameModel(ctx, req.State, &candidate, &state)
+		resp.Diagnostics.Append(diags...)
+		if same {
+			sealedSecret, isStale = state.SealedSecret, state.Stale
+		}
 	}
 
-	plan.SealedSecret = types.String{Value: string(sealedSecret)}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("sealed_secret"), sealedSecret)...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(stale), isStale)...)
+}
 
-	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+// priorPublicKeyHash returns the public_key_hash of state, computed from its public_key for states
+// written before public_key_hash existed.
+func priorPublicKeyHash(state *sealedSecretModel) types.String {
+	if state.PublicKeyHash.IsNull() {
+		if fingerprint, err := publicKeyFingerprint(state.PublicKey.ValueString()); err == nil {
+			return types.StringValue(fingerprint)
+		}
+	}
+	return state.PublicKeyHash
+}
+
+// sameModel reports whether a and b hold the same values once set into a state of the schema of
+// base.
+func sameModel(ctx context.Context, base tfsdk.State, a, b interface{}) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	sa, sb := base, base
+	diags.Append(sa.Set(ctx, a)...)
+	diags.Append(sb.Set(ctx, b)...)
+	if diags.HasError() {
+		return false, diags
+	}
+	return sa.Raw.Equal(sb.Raw), diags
+}
+
+func publicKeyFingerprint(pem string) (string, error) {
+	pk, err := kubeseal.ParsePublicKey([]byte(pem))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse public key: %w", err)
+	}
+	return kubeseal.Fingerprint(pk)
 }
 
 func (r *sealedSecretResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
-	tflog.Error(ctx, "Delete sealed secret resource")
-	return
+	tflog.Debug(ctx, "Delete sealed secret resource")
+}
+
+func createSealedSecret(ctx context.Context, plan *sealedSecretModel) ([]byte, error) {
+	ctx, secret, meta, err := buildSecret(ctx, plan)
+	if err != nil {
+		return nil, err
+	}
+	if err := setKeyInventory(plan, &secret); err != nil {
+		return nil, err
+	}
+
+	pk, err := kubeseal.ParsePublicKey([]byte(plan.PublicKey.ValueString()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	fingerprint, err := kubeseal.Fingerprint(pk)
+	if err != nil {
+		return nil, err
+	}
+	plan.PublicKeyHash = types.StringValue(fingerprint)
+
+	return kubeseal.NewSealer(kubeseal.StaticKey(pk), kubeseal.WithObjectMeta(meta)).Seal(ctx, secret)
 }
 
-func createSealedSecret(ctx context.Context, name, namespace, scope, secretType, publicKey string, data, stringData map[string]string) ([]byte, error) {
+// buildSecret renders the secret described by plan along with the metadata of the SealedSecret
+// object wrapping it, validating its name, namespace, keys and size like the API server would. The
+// SHA-256 of the files and data files read is recorded in plan, and the returned context masks
+// their values in logs.
+func buildSecret(ctx context.Context, plan *sealedSecretModel) (context.Context, v1.Secret, kubeseal.ObjectMeta, error) {
+	if err := k8s.ValidateName(plan.Name.ValueString()); err != nil {
+		return ctx, v1.Secret{}, kubeseal.ObjectMeta{}, err
+	}
+	if err := k8s.ValidateNamespace(plan.Namespace.ValueString()); err != nil {
+		return ctx, v1.Secret{}, kubeseal.ObjectMeta{}, err
+	}
+
+	data, err := tfMaptoMapStringString(plan.Data)
+	if err != nil {
+		return ctx, v1.Secret{}, kubeseal.ObjectMeta{}, fmt.Errorf("failed to convert data tf map to map[string]string: %w", err)
+	}
+	stringData, err := tfMaptoMapStringString(plan.StringData)
+	if err != nil {
+		return ctx, v1.Secret{}, kubeseal.ObjectMeta{}, fmt.Errorf("failed to convert stringdata tf map to map[string]string: %w", err)
+	}
+	dataBase64, err := tfMaptoMapStringString(plan.DataBase64)
+	if err != nil {
+		return ctx, v1.Secret{}, kubeseal.ObjectMeta{}, fmt.Errorf("failed to convert data_base64 tf map to map[string]string: %w", err)
+	}
+	for attr, m := range map[string]map[string]string{"data": data, "string_data": stringData, "data_base64": dataBase64} {
+		for k := range m {
+			if err := k8s.ValidateKey(k); err != nil {
+				return ctx, v1.Secret{}, kubeseal.ObjectMeta{}, fmt.Errorf("%s: %w", attr, err)
+			}
+		}
+	}
+	for k := range stringData {
+		if _, ok := data[k]; ok {
+			return ctx, v1.Secret{}, kubeseal.ObjectMeta{}, fmt.Errorf("key %q is set in both data and string_data", k)
+		}
+	}
+	fileValues, fileSources, dataFileHashes, err := readDataFiles(plan)
+	if err != nil {
+		return ctx, v1.Secret{}, kubeseal.ObjectMeta{}, err
+	}
+	plan.DataFilesSHA256 = fileHashesToMap(dataFileHashes)
+	ctx = maskLogStrings(ctx, mapStringValues(fileValues))
+	for k, v := range fileValues {
+		// string_data overrides single keys read from files
+		if _, ok := stringData[k]; ok {
+			continue
+		}
+		if _, ok := data[k]; ok {
+			return ctx, v1.Secret{}, kubeseal.ObjectMeta{}, fmt.Errorf("key %q is set in both data and %s", k, fileSources[k])
+		}
+		if _, ok := dataBase64[k]; ok {
+			return ctx, v1.Secret{}, kubeseal.ObjectMeta{}, fmt.Errorf("key %q is set in both data_base64 and %s", k, fileSources[k])
+		}
+		// file values are passed base64 encoded so that multi-line values survive the manifest
+		dataBase64[k] = base64.StdEncoding.EncodeToString([]byte(v))
+	}
+	fileContents, fileHashes, err := readFiles(plan.Files)
+	if err != nil {
+		return ctx, v1.Secret{}, kubeseal.ObjectMeta{}, err
+	}
+	contents := make([]string, 0, len(fileContents))
+	for _, v := range fileContents {
+		contents = append(contents, string(v))
+	}
+	ctx = maskLogStrings(ctx, contents)
+	for k, v := range fileContents {
+		if err := k8s.ValidateKey(k); err != nil {
+			return ctx, v1.Secret{}, kubeseal.ObjectMeta{}, fmt.Errorf("files: %w", err)
+		}
+		if _, ok := fileValues[k]; ok {
+			return ctx, v1.Secret{}, kubeseal.ObjectMeta{}, fmt.Errorf("key %q is set in both %s and files", k, fileSources[k])
+		}
+		for attr, m := range map[string]map[string]string{"data": data, "string_data": stringData, "data_base64": dataBase64} {
+			if _, ok := m[k]; ok {
+				return ctx, v1.Secret{}, kubeseal.ObjectMeta{}, fmt.Errorf("key %q is set in both %s and files", k, attr)
+			}
+		}
+		dataBase64[k] = base64.StdEncoding.EncodeToString(v)
+	}
+	plan.FilesSHA256 = fileHashesToMap(fileHashes)
+
+	labels, err := tfMaptoMapStringString(plan.Labels)
+	if err != nil {
+		return ctx, v1.Secret{}, kubeseal.ObjectMeta{}, fmt.Errorf("failed to convert labels tf map to map[string]string: %w", err)
+	}
+	annotations, err := tfMaptoMapStringString(plan.Annotations)
+	if err != nil {
+		return ctx, v1.Secret{}, kubeseal.ObjectMeta{}, fmt.Errorf("failed to convert annotations tf map to map[string]string: %w", err)
+	}
+
+	sealedSecretLabels, err := tfMaptoMapStringString(plan.SealedSecretLabels)
+	if err != nil {
+		return ctx, v1.Secret{}, kubeseal.ObjectMeta{}, fmt.Errorf("failed to convert sealedsecret_labels tf map to map[string]string: %w", err)
+	}
+	sealedSecretAnnotations, err := tfMaptoMapStringString(plan.SealedSecretAnnotations)
+	if err != nil {
+		return ctx, v1.Secret{}, kubeseal.ObjectMeta{}, fmt.Errorf("failed to convert sealedsecret_annotations tf map to map[string]string: %w", err)
+	}
+
+	scope := plan.Scope.ValueString()
+	if scope == "" {
+		scope = defaultScope
+	}
+
 	rawSecret := k8s.SecretManifest{
-		Name:        name,
-		Namespace:   namespace,
-		Type:        secretType,
-		Annotations: make(map[string]string),
+		Name:        plan.Name.ValueString(),
+		Namespace:   plan.Namespace.ValueString(),
+		Type:        plan.SecretType.ValueString(),
+		Labels:      labels,
+		Annotations: annotations,
+		DataBase64:  dataBase64,
+		Immutable:   plan.Immutable.ValueBool(),
+	}
+	for _, r := range plan.DockerRegistries {
+		rawSecret.DockerRegistries = append(rawSecret.DockerRegistries, k8s.DockerRegistry{
+			Server:   r.Server.ValueString(),
+			Username: r.Username.ValueString(),
+			Password: r.Password.ValueString(),
+			Email:    r.Email.ValueString(),
+		})
 	}
-	tflog.Error(ctx, fmt.Sprintf("scope is %s", scope))
+	if err := setControllerAnnotations(rawSecret.Annotations, plan.Managed, plan.Patch, plan.SkipSetOwnerReferences); err != nil {
+		return ctx, v1.Secret{}, kubeseal.ObjectMeta{}, err
+	}
+	tflog.Debug(ctx, "Build secret", map[string]interface{}{"name": plan.Name.ValueString(), "namespace": plan.Namespace.ValueString(), "scope": scope})
 	if scope == "namespace-wide" {
 		rawSecret.Annotations["sealedsecrets.bitnami.com/namespace-wide"] = "true"
 	} else if scope == "cluster-wide" {
 		rawSecret.Annotations["sealedsecrets.bitnami.com/cluster-wide"] = "true"
 	} else if scope == "strict" {
 	} else {
-		return nil, fmt.Errorf("scope must be one of namespace-wide, cluster-wide, strict or null (default=struct, given %s)", scope)
+		return ctx, v1.Secret{}, kubeseal.ObjectMeta{}, fmt.Errorf("scope must be one of namespace-wide, cluster-wide, strict or null (default=strict, given %s)", scope)
 	}
 
 	rawSecret.Data = make(map[string]interface{})
@@ -255,12 +804,48 @@ func createSealedSecret(ctx context.Context, name, namespace, scope, secretType,
 
 	secret, err := k8s.CreateSecret(&rawSecret)
 	if err != nil {
-		return nil, err
+		return ctx, v1.Secret{}, kubeseal.ObjectMeta{}, err
+	}
+	if err := k8s.ValidateSecretSize(&secret); err != nil {
+		return ctx, v1.Secret{}, kubeseal.ObjectMeta{}, err
 	}
 
-	block, _ := pem.Decode([]byte(publicKey))
-	cert, _ := x509.ParseCertificate(block.Bytes)
-	pk := cert.PublicKey.(*rsa.PublicKey)
+	return ctx, secret, kubeseal.ObjectMeta{
+		Labels:      sealedSecretLabels,
+		Annotations: sealedSecretAnnotations,
+	}, nil
+}
 
-	return kubeseal.SealSecret(secret, pk)
+// setControllerAnnotations sets the annotations of the managed, patch and skip_set_owner_references
+// attributes, in that order so that the first conflict is always the one reported.
+func setControllerAnnotations(annotations map[string]string, managed, patch, skipSetOwnerReferences types.Bool) error {
+	for _, a := range []struct {
+		key string
+		v   types.Bool
+	}{
+		{managedAnnotation, managed},
+		{patchAnnotation, patch},
+		{skipSetOwnerReferencesAnnotation, skipSetOwnerReferences},
+	} {
+		if err := setControllerAnnotation(annotations, a.key, a.v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setControllerAnnotation sets a controller directive annotation from its bool attribute, refusing
+// to silently override a conflicting value given through annotations.
+func setControllerAnnotation(annotations map[string]string, key string, v types.Bool) error {
+	if v.IsNull() || v.IsUnknown() {
+		return nil
+	}
+	value := strconv.FormatBool(v.ValueBool())
+	if existing, ok := annotations[key]; ok && existing != value {
+		return fmt.Errorf("annotation %s is set to %q but the matching attribute is %s", key, existing, value)
+	}
+	if v.ValueBool() {
+		annotations[key] = value
+	}
+	return nil
 }
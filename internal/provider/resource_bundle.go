@@ -0,0 +1,286 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/kubeseal"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	format  = "format"
+	secrets = "secret"
+)
+
+type sealedSecretBundleResource struct {
+	provider *providerData
+}
+
+type sealedSecretBundleModel struct {
+	Format    types.String                   `tfsdk:"format"`
+	Secrets   []sealedSecretBundleEntryModel `tfsdk:"secret"`
+	PublicKey types.String                   `tfsdk:"public_key"`
+	Cluster   types.String                   `tfsdk:"cluster"`
+	Manifest  types.String                   `tfsdk:"manifest"`
+}
+
+type sealedSecretBundleEntryModel struct {
+	Name                    types.String `tfsdk:"name"`
+	Namespace               types.String `tfsdk:"namespace"`
+	Scope                   types.String `tfsdk:"scope"`
+	SecretType              types.String `tfsdk:"type"`
+	StringData              types.Map    `tfsdk:"string_data"`
+	Data                    types.Map    `tfsdk:"data"`
+	DataBase64              types.Map    `tfsdk:"data_base64"`
+	Labels                  types.Map    `tfsdk:"labels"`
+	Annotations             types.Map    `tfsdk:"annotations"`
+	SealedSecretLabels      types.Map    `tfsdk:"sealedsecret_labels"`
+	SealedSecretAnnotations types.Map    `tfsdk:"sealedsecret_annotations"`
+}
+
+func NewSealedSecretBundleResource() resource.Resource {
+	return &sealedSecretBundleResource{}
+}
+
+func (r *sealedSecretBundleResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = "sealedsecret_bundle"
+}
+
+func (r *sealedSecretBundleResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	publicKeyAttr, clusterAttr := publicKeyAttributes("The public key used to seal every secret of the bundle.")
+	resp.Schema = schema.Schema{
+		Description: "Seals a set of secrets with the same public key into a single manifest, ordered by namespace and name",
+		Attributes: map[string]schema.Attribute{
+			format: schema.StringAttribute{
+				Computed: true,
+				Optional: true,
+				Default:  stringdefault.StaticString(kubeseal.BundleFormatYAML),
+				Validators: []validator.String{
+					stringvalidator.OneOf(kubeseal.BundleFormatYAML, kubeseal.BundleFormatList),
+				},
+				Description: "Output format: yaml for a multi-document YAML stream, list for a v1/List",
+			},
+			publicKey: publicKeyAttr,
+			clusters:  clusterAttr,
+			manifest: schema.StringAttribute{
+				Computed:    true,
+				Description: "All sealed secrets of the bundle in a single manifest.",
+			},
+		},
+		Blocks: map[string]schema.Block{
+			secrets: bundleSecretBlock(),
+		},
+	}
+}
+
+// bundleSecretBlock is the schema of a single secret inside resources sealing several of them.
+func bundleSecretBlock() schema.ListNestedBlock {
+	return schema.ListNestedBlock{
+		Validators: []validator.List{
+			listvalidator.IsRequired(),
+			listvalidator.SizeAtLeast(1),
+		},
+		Description: "A secret to seal into the bundle",
+		NestedObject: schema.NestedBlockObject{
+			Attributes: map[string]schema.Attribute{
+				name: schema.StringAttribute{
+					Required:    true,
+					Description: "name of the secret, must be unique within its namespace",
+				},
+				namespace: schema.StringAttribute{
+					Required:    true,
+					Description: "namespace of the secret",
+				},
+				scope: schema.StringAttribute{
+					Optional: true,
+					Validators: []validator.String{
+						scopeValidator(),
+					},
+					Description: "Set the scope of the sealed secret: strict, namespace-wide, cluster-wide",
+				},
+				secretType: schema.StringAttribute{
+					Optional:    true,
+					Description: "The secret type (ex. Opaque), defaults to Opaque",
+				},
+				data: schema.MapAttribute{
+					ElementType: types.StringType,
+					Optional:    true,
+					Sensitive:   true,
+					Description: "Key/value pairs to populate the secret. The value will be base64 encoded",
+				},
+				stringData: schema.MapAttribute{
+					ElementType: types.StringType,
+					Optional:    true,
+					Sensitive:   true,
+					Description: "Key/value pairs to populate the secret.",
+				},
+				dataBase64: schema.MapAttribute{
+					ElementType: types.StringType,
+					Optional:    true,
+					Sensitive:   true,
+					Description: "Key/value pairs to populate the secret. The value must already be base64 encoded and is passed through untouched",
+				},
+				labels: schema.MapAttribute{
+					ElementType: types.StringType,
+					Optional:    true,
+					Description: "Labels to set on the secret",
+				},
+				annotations: schema.MapAttribute{
+					ElementType: types.StringType,
+					Optional:    true,
+					Description: "Annotations to set on the secret",
+				},
+				sealedSecretLabels: schema.MapAttribute{
+					ElementType: types.StringType,
+					Optional:    true,
+					Description: "Labels to set on the SealedSecret object itself",
+				},
+				sealedSecretAnnotations: schema.MapAttribute{
+					ElementType: types.StringType,
+					Optional:    true,
+					Description: "Annotations to set on the SealedSecret object itself",
+				},
+			},
+		},
+	}
+}
+
+func (r *sealedSecretBundleResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.provider = resourceProviderData(req, resp)
+}
+
+func (r *sealedSecretBundleResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	modifyPlanPublicKey(ctx, r.provider, req, resp, path.Root(manifest))
+}
+
+func (r *sealedSecretBundleResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	tflog.Debug(ctx, "Create sealed secret bundle resource")
+	var plan sealedSecretBundleModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx = maskSecretValues(ctx, bundleSecretValues(plan.Secrets))
+
+	bundle, err := createSealedSecretBundle(ctx, &plan)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to seal secret bundle", err.Error())
+		return
+	}
+
+	plan.Manifest = types.StringValue(string(bundle))
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *sealedSecretBundleResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	tflog.Debug(ctx, "Read sealed secret bundle resource")
+}
+
+func (r *sealedSecretBundleResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	tflog.Debug(ctx, "Update sealed secret bundle resource")
+	var plan sealedSecretBundleModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx = maskSecretValues(ctx, bundleSecretValues(plan.Secrets))
+
+	bundle, err := createSealedSecretBundle(ctx, &plan)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to seal secret bundle", err.Error())
+		return
+	}
+
+	plan.Manifest = types.StringValue(string(bundle))
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *sealedSecretBundleResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	tflog.Debug(ctx, "Delete sealed secret bundle resource")
+}
+
+// model converts a bundle entry into the model of a single sealedsecret resource.
+func (e *sealedSecretBundleEntryModel) model(publicKey types.String) *sealedSecretModel {
+	secretType := e.SecretType
+	if secretType.IsNull() {
+		secretType = types.StringValue("Opaque")
+	}
+	return &sealedSecretModel{
+		Name:                    e.Name,
+		Namespace:               e.Namespace,
+		Scope:                   e.Scope,
+		SecretType:              secretType,
+		StringData:              e.StringData,
+		Data:                    e.Data,
+		DataBase64:              e.DataBase64,
+		Labels:                  e.Labels,
+		Annotations:             e.Annotations,
+		SealedSecretLabels:      e.SealedSecretLabels,
+		SealedSecretAnnotations: e.SealedSecretAnnotations,
+		PublicKey:               publicKey,
+	}
+}
+
+func createSealedSecretBundle(ctx context.Context, plan *sealedSecretBundleModel) ([]byte, error) {
+	pk, err := kubeseal.ParsePublicKey([]byte(plan.PublicKey.ValueString()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	entries := make([]sealedSecretBundleEntryModel, len(plan.Secrets))
+	copy(entries, plan.Secrets)
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Namespace.ValueString() != entries[j].Namespace.ValueString() {
+			return entries[i].Namespace.ValueString() < entries[j].Namespace.ValueString()
+		}
+		return entries[i].Name.ValueString() < entries[j].Name.ValueString()
+	})
+
+	manifests := make([][]byte, 0, len(entries))
+	for i, e := range entries {
+		if i > 0 && e.Namespace.ValueString() == entries[i-1].Namespace.ValueString() && e.Name.ValueString() == entries[i-1].Name.ValueString() {
+			return nil, fmt.Errorf("secret %s/%s is defined more than once", e.Namespace.ValueString(), e.Name.ValueString())
+		}
+
+		ctx, secret, meta, err := buildSecret(ctx, e.model(plan.PublicKey))
+		if err != nil {
+			return nil, fmt.Errorf("secret %s/%s: %w", e.Namespace.ValueString(), e.Name.ValueString(), err)
+		}
+		sealed, err := kubeseal.NewSealer(kubeseal.StaticKey(pk), kubeseal.WithObjectMeta(meta)).Seal(ctx, secret)
+		if err != nil {
+			return nil, fmt.Errorf("secret %s/%s: %w", e.Namespace.ValueString(), e.Name.ValueString(), err)
+		}
+		manifests = append(manifests, sealed)
+	}
+
+	return kubeseal.Bundle(manifests, plan.Format.ValueString())
+}
+
+// equal reports whether both entries describe the same secret.
+func (e *sealedSecretBundleEntryModel) equal(o *sealedSecretBundleEntryModel) bool {
+	return e.Name.Equal(o.Name) &&
+		e.Namespace.Equal(o.Namespace) &&
+		e.Scope.Equal(o.Scope) &&
+		e.SecretType.Equal(o.SecretType) &&
+		e.StringData.Equal(o.StringData) &&
+		e.Data.Equal(o.Data) &&
+		e.DataBase64.Equal(o.DataBase64) &&
+		e.Labels.Equal(o.Labels) &&
+		e.Annotations.Equal(o.Annotations) &&
+		e.SealedSecretLabels.Equal(o.SealedSecretLabels) &&
+		e.SealedSecretAnnotations.Equal(o.SealedSecretAnnotations)
+}
@@ -0,0 +1,119 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/kubeseal"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateSealedSecretBundle(t *testing.T) {
+	entry := func(entryName, entryNamespace string) sealedSecretBundleEntryModel {
+		e := testBundleEntry(entryName, map[string]string{"keyAA": "valueAA"})
+		e.Namespace = types.StringValue(entryNamespace)
+		return e
+	}
+	tests := []struct {
+		Name          string
+		Secrets       []sealedSecretBundleEntryModel
+		ExpectedOrder []string
+		ExpectedError string
+	}{
+		{
+			Name: "ordered by namespace and name",
+			Secrets: []sealedSecretBundleEntryModel{
+				entry("name-aa", "ns-bb"),
+				entry("name-bb", "ns-aa"),
+				entry("name-aa", "ns-aa"),
+			},
+			ExpectedOrder: []string{"ns-aa/name-aa", "ns-aa/name-bb", "ns-bb/name-aa"},
+		},
+		{
+			Name: "same name in another namespace",
+			Secrets: []sealedSecretBundleEntryModel{
+				entry("name-aa", "ns-bb"),
+				entry("name-aa", "ns-aa"),
+			},
+			ExpectedOrder: []string{"ns-aa/name-aa", "ns-bb/name-aa"},
+		},
+		{
+			Name: "duplicate",
+			Secrets: []sealedSecretBundleEntryModel{
+				entry("name-aa", "ns-aa"),
+				entry("name-bb", "ns-aa"),
+				entry("name-aa", "ns-aa"),
+			},
+			ExpectedError: "secret ns-aa/name-aa is defined more than once",
+		},
+	}
+
+	publicKeyPEM := types.StringValue(testCertPEM(t))
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			plan := sealedSecretBundleModel{
+				Format:    types.StringValue(kubeseal.BundleFormatYAML),
+				Secrets:   tc.Secrets,
+				PublicKey: publicKeyPEM,
+			}
+			bundle, err := createSealedSecretBundle(context.Background(), &plan)
+			if tc.ExpectedError != "" {
+				assert.EqualError(t, err, tc.ExpectedError)
+				return
+			}
+			assert.Nil(t, err)
+
+			var order []string
+			for _, document := range strings.Split(string(bundle), "---\n") {
+				sealed, err := kubeseal.ParseSealedSecret([]byte(document))
+				assert.Nil(t, err)
+				order = append(order, sealed.Namespace+"/"+sealed.Name)
+			}
+			assert.Equal(t, tc.ExpectedOrder, order)
+		})
+	}
+}
+
+func TestBundleFormatValidation(t *testing.T) {
+	ctx := context.Background()
+	server := providerserver.NewProtocol6(New())()
+	schemas, err := server.GetProviderSchema(ctx, &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundleType := schemas.ResourceSchemas["sealedsecret_bundle"].ValueType()
+
+	for _, tc := range []struct {
+		Format        string
+		ExpectedError bool
+	}{
+		{Format: kubeseal.BundleFormatYAML},
+		{Format: kubeseal.BundleFormatList},
+		{Format: "yml", ExpectedError: true},
+	} {
+		t.Run(tc.Format, func(t *testing.T) {
+			config := objectValue(bundleType, map[string]tftypes.Value{
+				format: tftypes.NewValue(tftypes.String, tc.Format),
+			})
+			resp, err := server.ValidateResourceConfig(ctx, &tfprotov6.ValidateResourceConfigRequest{
+				TypeName: "sealedsecret_bundle",
+				Config:   dynamicValue(t, config),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			formatErrors := 0
+			for _, d := range resp.Diagnostics {
+				if d.Attribute.Equal(tftypes.NewAttributePath().WithAttributeName(format)) {
+					formatErrors++
+				}
+			}
+			assert.Equal(t, tc.ExpectedError, formatErrors > 0)
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/k8s"
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/kubeseal"
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// secretHashKey is the private state key of the secretHash of the Secret as last sealed. It is kept
+// out of the attributes, where a plain hash of low entropy values could be brute forced from the
+// state or the plan output.
+const secretHashKey = "secret_sha256"
+
+type sealedSecretFromClusterResource struct {
+	provider *providerData
+}
+
+type sealedSecretFromClusterModel struct {
+	Name         types.String `tfsdk:"name"`
+	Namespace    types.String `tfsdk:"namespace"`
+	Scope        types.String `tfsdk:"scope"`
+	PublicKey    types.String `tfsdk:"public_key"`
+	Cluster      types.String `tfsdk:"cluster"`
+	SealedSecret types.String `tfsdk:"sealed_secret"`
+}
+
+func NewSealedSecretFromClusterResource() resource.Resource {
+	return &sealedSecretFromClusterResource{}
+}
+
+func (r *sealedSecretFromClusterResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = "sealedsecret_from_cluster"
+}
+
+func (r *sealedSecretFromClusterResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	publicKeyAttr, clusterAttr := publicKeyAttributes("The public key used to seal the secret.")
+	resp.Schema = schema.Schema{
+		Description: "Seals a Secret which already exists in the cluster, keeping its name, namespace, type, labels and annotations. " +
+			"The Secret is read through the kubernetes block of the provider and only the sealed output is stored in the state. " +
+			"It is sealed again when the Secret changes, and removed from the state when the Secret is deleted. " +
+			"Can be imported with the ID <namespace>/<name>",
+		Attributes: map[string]schema.Attribute{
+			name: schema.StringAttribute{
+				Required: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Description: "name of the secret to read from the cluster",
+			},
+			namespace: schema.StringAttribute{
+				Required: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Description: "namespace of the secret to read from the cluster",
+			},
+			scope: schema.StringAttribute{
+				Optional: true,
+				Validators: []validator.String{
+					scopeValidator(),
+				},
+				Description: "Set the scope of the sealed secret: strict, namespace-wide, cluster-wide. Defaults to the scope annotations of the secret",
+			},
+			publicKey: publicKeyAttr,
+			clusters:  clusterAttr,
+			"sealed_secret": schema.StringAttribute{
+				Computed:    true,
+				Description: "The sealed secret manifest.",
+			},
+		},
+	}
+}
+
+func (r *sealedSecretFromClusterResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.provider = resourceProviderData(req, resp)
+}
+
+func (r *sealedSecretFromClusterResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	modifyPlanPublicKey(ctx, r.provider, req, resp, path.Root("sealed_secret"))
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	r.modifyPlanSecretHash(ctx, req.Private, req, resp)
+}
+
+// modifyPlanSecretHash seals the Secret again when its secretHash differs from the one in private
+// state, a Secret sealed before its hash was kept included.
+func (r *sealedSecretFromClusterResource) modifyPlanSecretHash(ctx context.Context, private privateState, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() || r.provider == nil || r.provider.Client == nil {
+		return
+	}
+	var state, plan sealedSecretFromClusterModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	// a secret sealed again anyway is hashed when it is
+	if resp.Diagnostics.HasError() || plan.SealedSecret.IsUnknown() {
+		return
+	}
+
+	secret, err := r.provider.Client.GetSecret(ctx, state.Namespace.ValueString(), state.Name.ValueString())
+	if err != nil {
+		tflog.Debug(ctx, "Secret cannot be read at plan time", map[string]interface{}{"error": err.Error()})
+		return
+	}
+	sealed, diags := sealedSecretHash(ctx, private)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || secretHash(secret) == sealed {
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("sealed_secret"), types.StringUnknown())...)
+}
+
+func (r *sealedSecretFromClusterResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	tflog.Debug(ctx, "Create sealed secret from cluster resource")
+	var plan sealedSecretFromClusterModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	sealedSecret, hash, err := r.sealClusterSecret(ctx, &plan)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to seal secret from cluster", err.Error())
+		return
+	}
+
+	plan.SealedSecret = types.StringValue(string(sealedSecret))
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+	resp.Diagnostics.Append(setSealedSecretHash(ctx, resp.Private, hash)...)
+}
+
+// Read removes the resource from the state when the Secret was deleted from the cluster. A Secret
+// which changed is sealed again by the plan, see modifyPlanSecretHash.
+func (r *sealedSecretFromClusterResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	tflog.Debug(ctx, "Read sealed secret from cluster resource")
+	var state sealedSecretFromClusterModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() || r.provider == nil || r.provider.Client == nil {
+		return
+	}
+
+	secret, err := r.provider.Client.GetSecret(ctx, state.Namespace.ValueString(), state.Name.ValueString())
+	if apierrors.IsNotFound(err) {
+		tflog.Debug(ctx, "Secret deleted from the cluster", map[string]interface{}{
+			"name":      state.Name.ValueString(),
+			"namespace": state.Namespace.ValueString(),
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read secret from cluster", err.Error())
+		return
+	}
+	sealed, diags := sealedSecretHash(ctx, req.Private)
+	resp.Diagnostics.Append(diags...)
+	if sealed != "" && secretHash(secret) != sealed {
+		tflog.Debug(ctx, "Secret changed in the cluster since it was sealed", map[string]interface{}{
+			"name":      state.Name.ValueString(),
+			"namespace": state.Namespace.ValueString(),
+		})
+	}
+}
+
+func (r *sealedSecretFromClusterResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	tflog.Debug(ctx, "Update sealed secret from cluster resource")
+	var plan sealedSecretFromClusterModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	sealedSecret, hash, err := r.sealClusterSecret(ctx, &plan)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to seal secret from cluster", err.Error())
+		return
+	}
+
+	plan.SealedSecret = types.StringValue(string(sealedSecret))
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+	resp.Diagnostics.Append(setSealedSecretHash(ctx, resp.Private, hash)...)
+}
+
+func (r *sealedSecretFromClusterResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	tflog.Debug(ctx, "Delete sealed secret from cluster resource")
+}
+
+// ImportState only records the secret to migrate, it is sealed by the update following the
+// import once public_key is known from the configuration.
+func (r *sealedSecretFromClusterResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	parts := strings.Split(req.ID, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		resp.Diagnostics.AddError("Invalid import ID", fmt.Sprintf("expected <namespace>/<name>, given %q", req.ID))
+		return
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root(namespace), parts[0])...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root(name), parts[1])...)
+}
+
+// sealClusterSecret seals the Secret of plan, returning the manifest along with the secretHash of
+// the Secret.
+func (r *sealedSecretFromClusterResource) sealClusterSecret(ctx context.Context, plan *sealedSecretFromClusterModel) ([]byte, string, error) {
+	if r.provider == nil || r.provider.Client == nil {
+		return nil, "", fmt.Errorf("the provider has no kubernetes block to read the secret with")
+	}
+
+	secret, err := r.provider.Client.GetSecret(ctx, plan.Namespace.ValueString(), plan.Name.ValueString())
+	if err != nil {
+		return nil, "", err
+	}
+	hash := secretHash(secret)
+	if !plan.Scope.IsNull() {
+		var s ssv1alpha1.SealingScope
+		if err := s.Set(plan.Scope.ValueString()); err != nil {
+			return nil, "", err
+		}
+		secret.Annotations = ssv1alpha1.UpdateScopeAnnotations(secret.Annotations, s)
+	}
+	if err := k8s.ValidateSecret(secret); err != nil {
+		return nil, "", err
+	}
+
+	pk, err := kubeseal.ParsePublicKey([]byte(plan.PublicKey.ValueString()))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	sealed, err := kubeseal.NewSealer(kubeseal.StaticKey(pk)).Seal(ctx, *secret)
+	return sealed, hash, err
+}
+
+// sealedSecretHash returns the secretHash of the Secret as last sealed, empty when there is none.
+func sealedSecretHash(ctx context.Context, private privateState) (string, diag.Diagnostics) {
+	content, diags := private.GetKey(ctx, secretHashKey)
+	if diags.HasError() || content == nil {
+		return "", diags
+	}
+	var hash string
+	if err := json.Unmarshal(content, &hash); err != nil {
+		diags.AddError("Failed to read private state", err.Error())
+	}
+	return hash, diags
+}
+
+func setSealedSecretHash(ctx context.Context, private privateState, hash string) diag.Diagnostics {
+	content, _ := json.Marshal(hash)
+	return private.SetKey(ctx, secretHashKey, content)
+}
+
+// secretHash is the SHA-256 of the parts of secret which end up in its sealed manifest.
+func secretHash(secret *v1.Secret) string {
+	content, _ := json.Marshal(struct {
+		Type        v1.SecretType
+		Labels      map[string]string
+		Annotations map[string]string
+		Data        map[string][]byte
+	}{secret.Type, secret.Labels, secret.Annotations, secret.Data})
+	return hashBytes(content)
+}
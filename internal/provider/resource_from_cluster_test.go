@@ -0,0 +1,185 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func clusterSecret(value string) *v1.Secret {
+	return &v1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{Name: "name-aa", Namespace: "ns-aa", ResourceVersion: value},
+		Type:       v1.SecretTypeOpaque,
+		Data:       map[string][]byte{"keyAA": []byte(value)},
+	}
+}
+
+// secretMock serves secret as name-aa in ns-aa, or answers not found when it is nil.
+func secretMock(t *testing.T, secret *v1.Secret) roundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "/api/v1/namespaces/ns-aa/secrets/name-aa", req.URL.Path)
+		if secret == nil {
+			return statusResponse(t, http.StatusNotFound, metav1.StatusReasonNotFound), nil
+		}
+		return jsonResponse(t, http.StatusOK, secret), nil
+	}
+}
+
+// testPrivateState is a privateState kept in a map.
+type testPrivateState map[string][]byte
+
+func (p testPrivateState) GetKey(_ context.Context, key string) ([]byte, diag.Diagnostics) {
+	return p[key], nil
+}
+
+func (p testPrivateState) SetKey(_ context.Context, key string, value []byte) diag.Diagnostics {
+	p[key] = value
+	return nil
+}
+
+func fromClusterState(ctx context.Context, t *testing.T, r resource.Resource) tfsdk.State {
+	var schemaResp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+	state := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}
+	diags := state.Set(ctx, &sealedSecretFromClusterModel{
+		Name:         types.StringValue("name-aa"),
+		Namespace:    types.StringValue("ns-aa"),
+		Scope:        types.StringNull(),
+		PublicKey:    types.StringValue("public-key"),
+		Cluster:      types.StringNull(),
+		SealedSecret: types.StringValue("sealed-secret"),
+	})
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	return state
+}
+
+func TestSecretHash(t *testing.T) {
+	assert.Equal(t, secretHash(clusterSecret("valueAA")), secretHash(clusterSecret("valueAA")))
+	assert.NotEqual(t, secretHash(clusterSecret("valueAA")), secretHash(clusterSecret("valueBB")))
+
+	labelled := clusterSecret("valueAA")
+	labelled.Labels = map[string]string{"labelAA": "valueAA"}
+	assert.NotEqual(t, secretHash(clusterSecret("valueAA")), secretHash(labelled))
+
+	// server side metadata is not sealed
+	updated := clusterSecret("valueAA")
+	updated.ResourceVersion = "2"
+	assert.Equal(t, secretHash(clusterSecret("valueAA")), secretHash(updated))
+}
+
+func TestFromClusterRead(t *testing.T) {
+	tests := []struct {
+		Name            string
+		Secret          *v1.Secret
+		ExpectedRemoved bool
+	}{
+		{
+			Name:   "unchanged",
+			Secret: clusterSecret("valueAA"),
+		},
+		{
+			Name:   "changed",
+			Secret: clusterSecret("valueBB"),
+		},
+		{
+			Name:            "deleted",
+			ExpectedRemoved: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := context.Background()
+			r := &sealedSecretFromClusterResource{provider: &providerData{Client: testClient(t, secretMock(t, tc.Secret))}}
+			state := fromClusterState(ctx, t, r)
+
+			resp := resource.ReadResponse{State: state}
+			r.Read(ctx, resource.ReadRequest{State: state}, &resp)
+			assert.False(t, resp.Diagnostics.HasError(), resp.Diagnostics)
+			assert.Equal(t, tc.ExpectedRemoved, resp.State.Raw.IsNull())
+		})
+	}
+}
+
+func TestSealedSecretHash(t *testing.T) {
+	ctx := context.Background()
+	private := testPrivateState{}
+
+	hash, diags := sealedSecretHash(ctx, private)
+	assert.False(t, diags.HasError(), diags)
+	assert.Equal(t, "", hash)
+
+	assert.False(t, setSealedSecretHash(ctx, private, "hashAA").HasError())
+	hash, diags = sealedSecretHash(ctx, private)
+	assert.False(t, diags.HasError(), diags)
+	assert.Equal(t, "hashAA", hash)
+}
+
+func TestFromClusterModifyPlan(t *testing.T) {
+	tests := []struct {
+		Name                 string
+		Secret               *v1.Secret
+		Sealed               *v1.Secret
+		ExpectedSealedSecret types.String
+	}{
+		{
+			Name:                 "unchanged",
+			Secret:               clusterSecret("valueAA"),
+			Sealed:               clusterSecret("valueAA"),
+			ExpectedSealedSecret: types.StringValue("sealed-secret"),
+		},
+		{
+			Name:                 "changed",
+			Secret:               clusterSecret("valueBB"),
+			Sealed:               clusterSecret("valueAA"),
+			ExpectedSealedSecret: types.StringUnknown(),
+		},
+		{
+			Name:                 "sealed before its hash was kept",
+			Secret:               clusterSecret("valueAA"),
+			ExpectedSealedSecret: types.StringUnknown(),
+		},
+		{
+			Name:                 "deleted after refresh",
+			Sealed:               clusterSecret("valueAA"),
+			ExpectedSealedSecret: types.StringValue("sealed-secret"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := context.Background()
+			r := &sealedSecretFromClusterResource{provider: &providerData{Client: testClient(t, secretMock(t, tc.Secret))}}
+			state := fromClusterState(ctx, t, r)
+			plan := tfsdk.Plan{Schema: state.Schema, Raw: state.Raw}
+			config := tfsdk.Config{Schema: state.Schema, Raw: state.Raw}
+			private := testPrivateState{}
+			if tc.Sealed != nil {
+				assert.False(t, setSealedSecretHash(ctx, private, secretHash(tc.Sealed)).HasError())
+			}
+
+			resp := resource.ModifyPlanResponse{Plan: plan}
+			r.modifyPlanSecretHash(ctx, private, resource.ModifyPlanRequest{Config: config, State: state, Plan: plan}, &resp)
+			assert.False(t, resp.Diagnostics.HasError(), resp.Diagnostics)
+
+			var planned sealedSecretFromClusterModel
+			resp.Diagnostics.Append(resp.Plan.Get(ctx, &planned)...)
+			assert.Equal(t, tc.ExpectedSealedSecret, planned.SealedSecret)
+		})
+	}
+}
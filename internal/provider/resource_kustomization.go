@@ -0,0 +1,446 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	fpath "path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/kubeseal"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	directory         = "path"
+	commonLabels      = "common_labels"
+	files             = "files"
+	kustomizationFile = "kustomization.yaml"
+
+	// writtenFilesKey is the private state key of the SHA-256 of the files as last written, which
+	// tells files modified since apart once files holds their refreshed hashes.
+	writtenFilesKey = "files"
+)
+
+// privateState is implemented by the private state of the requests and responses of a resource.
+type privateState interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+	SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics
+}
+
+type sealedSecretKustomizationResource struct {
+	provider *providerData
+}
+
+type sealedSecretKustomizationModel struct {
+	Path         types.String                   `tfsdk:"path"`
+	CommonLabels types.Map                      `tfsdk:"common_labels"`
+	Secrets      []sealedSecretBundleEntryModel `tfsdk:"secret"`
+	PublicKey    types.String                   `tfsdk:"public_key"`
+	Cluster      types.String                   `tfsdk:"cluster"`
+	Files        types.Map                      `tfsdk:"files"`
+}
+
+func NewSealedSecretKustomizationResource() resource.Resource {
+	return &sealedSecretKustomizationResource{}
+}
+
+func (r *sealedSecretKustomizationResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = "sealedsecret_kustomization"
+}
+
+func (r *sealedSecretKustomizationResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	publicKeyAttr, clusterAttr := publicKeyAttributes("The public key used to seal every secret.")
+	resp.Schema = schema.Schema{
+		Description: "Writes sealed secrets into a directory, one file per secret, along with a kustomization.yaml listing them. " +
+			"Other files in the directory are never modified nor removed, YAML files which are not generated are reported",
+		Attributes: map[string]schema.Attribute{
+			directory: schema.StringAttribute{
+				Required: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Description: "Directory to write the sealed secrets and kustomization.yaml into, created if missing",
+			},
+			commonLabels: schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "commonLabels of the generated kustomization.yaml",
+			},
+			publicKey: publicKeyAttr,
+			clusters:  clusterAttr,
+			files: schema.MapAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "SHA-256 of every generated file, keyed by file name. Files modified or removed outside of Terraform are written again",
+			},
+		},
+		Blocks: map[string]schema.Block{
+			secrets: bundleSecretBlock(),
+		},
+	}
+}
+
+func (r *sealedSecretKustomizationResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.provider = resourceProviderData(req, resp)
+}
+
+func (r *sealedSecretKustomizationResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	modifyPlanPublicKey(ctx, r.provider, req, resp, path.Root(files))
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() || resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Read refreshed files from the directory, the files differing from the ones written are
+	// written again
+	var state types.Map
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root(files), &state)...)
+	written, diags := writtenFiles(ctx, req.Private, state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || state.Equal(hashesToMap(written)) {
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(files), types.MapUnknown(types.StringType))...)
+}
+
+func (r *sealedSecretKustomizationResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	tflog.Debug(ctx, "Create sealed secret kustomization resource")
+	var plan sealedSecretKustomizationModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx = maskSecretValues(ctx, bundleSecretValues(plan.Secrets))
+
+	hashes, stray, err := writeKustomization(ctx, &plan, nil, nil)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to write kustomization", err.Error())
+		return
+	}
+	addStrayFilesWarning(&resp.Diagnostics, plan.Path.ValueString(), stray)
+
+	plan.Files = hashesToMap(hashes)
+
+	resp.Diagnostics.Append(setWrittenFiles(ctx, resp.Private, hashes)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *sealedSecretKustomizationResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	tflog.Debug(ctx, "Read sealed secret kustomization resource")
+	var state sealedSecretKustomizationModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	written, diags := writtenFiles(ctx, req.Private, state.Files)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	// keep the hashes of states written before the private state was, files is refreshed below
+	resp.Diagnostics.Append(setWrittenFiles(ctx, resp.Private, written)...)
+
+	current, drift, err := kustomizationDrift(state.Path.ValueString(), written)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read kustomization", err.Error())
+		return
+	}
+	if len(drift) > 0 {
+		resp.Diagnostics.AddWarning(
+			"Kustomization directory changed outside of Terraform",
+			fmt.Sprintf("%s: %s. Generated files will be written again, other files are left untouched.", state.Path.ValueString(), strings.Join(drift, ", ")),
+		)
+	}
+
+	state.Files = hashesToMap(current)
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+func (r *sealedSecretKustomizationResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	tflog.Debug(ctx, "Update sealed secret kustomization resource")
+	var plan, state sealedSecretKustomizationModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx = maskSecretValues(ctx, bundleSecretValues(plan.Secrets))
+
+	written, diags := writtenFiles(ctx, req.Private, state.Files)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	hashes, stray, err := writeKustomization(ctx, &plan, &state, written)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to write kustomization", err.Error())
+		return
+	}
+	addStrayFilesWarning(&resp.Diagnostics, plan.Path.ValueString(), stray)
+
+	plan.Files = hashesToMap(hashes)
+
+	resp.Diagnostics.Append(setWrittenFiles(ctx, resp.Private, hashes)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *sealedSecretKustomizationResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	tflog.Debug(ctx, "Delete sealed secret kustomization resource")
+	var state sealedSecretKustomizationModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	for name := range state.Files.Elements() {
+		if err := os.Remove(fpath.Join(state.Path.ValueString(), name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			resp.Diagnostics.AddError("Failed to remove file", err.Error())
+		}
+	}
+}
+
+// secretFileName is unambiguous because neither namespaces nor names may contain underscores.
+func secretFileName(e *sealedSecretBundleEntryModel) string {
+	return e.Namespace.ValueString() + "_" + e.Name.ValueString() + ".yaml"
+}
+
+// writeKustomization writes the secrets of plan and the kustomization.yaml listing them, returning
+// the SHA-256 of every file written and the YAML files of the directory which were not. Secrets
+// unchanged since prior whose file is still as written are not re-sealed, so their ciphertext does
+// not churn. Of the other files, only the written ones no longer generated are removed.
+func writeKustomization(ctx context.Context, plan, prior *sealedSecretKustomizationModel, written map[string]string) (map[string]string, []string, error) {
+	pk, err := kubeseal.ParsePublicKey([]byte(plan.PublicKey.ValueString()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	if err := os.MkdirAll(plan.Path.ValueString(), 0o755); err != nil {
+		return nil, nil, err
+	}
+
+	priorEntries := make(map[string]*sealedSecretBundleEntryModel)
+	if prior != nil && prior.PublicKey.Equal(plan.PublicKey) {
+		for i := range prior.Secrets {
+			priorEntries[secretFileName(&prior.Secrets[i])] = &prior.Secrets[i]
+		}
+	}
+
+	hashes := make(map[string]string, len(plan.Secrets)+1)
+	resources := make([]string, 0, len(plan.Secrets))
+	for i := range plan.Secrets {
+		e := &plan.Secrets[i]
+		fileName := secretFileName(e)
+		if _, ok := hashes[fileName]; ok {
+			return nil, nil, fmt.Errorf("secret %s/%s is defined more than once", e.Namespace.ValueString(), e.Name.ValueString())
+		}
+		resources = append(resources, fileName)
+		filePath := fpath.Join(plan.Path.ValueString(), fileName)
+
+		if p, ok := priorEntries[fileName]; ok && p.equal(e) {
+			if current, err := fileHash(filePath); err == nil && current == written[fileName] {
+				hashes[fileName] = current
+				continue
+			}
+		}
+
+		ctx, secret, meta, err := buildSecret(ctx, e.model(plan.PublicKey))
+		if err != nil {
+			return nil, nil, fmt.Errorf("secret %s/%s: %w", e.Namespace.ValueString(), e.Name.ValueString(), err)
+		}
+		sealed, err := kubeseal.NewSealer(kubeseal.StaticKey(pk), kubeseal.WithObjectMeta(meta)).Seal(ctx, secret)
+		if err != nil {
+			return nil, nil, fmt.Errorf("secret %s/%s: %w", e.Namespace.ValueString(), e.Name.ValueString(), err)
+		}
+		if hashes[fileName], err = writeFileIfChanged(filePath, sealed); err != nil {
+			return nil, nil, err
+		}
+	}
+	sort.Strings(resources)
+
+	kustomization, err := renderKustomization(plan.CommonLabels, resources)
+	if err != nil {
+		return nil, nil, err
+	}
+	if hashes[kustomizationFile], err = writeFileIfChanged(fpath.Join(plan.Path.ValueString(), kustomizationFile), kustomization); err != nil {
+		return nil, nil, err
+	}
+
+	for name := range written {
+		if _, ok := hashes[name]; ok {
+			continue
+		}
+		tflog.Debug(ctx, "Remove file no longer generated", map[string]interface{}{"file": name})
+		if err := os.Remove(fpath.Join(plan.Path.ValueString(), name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, err
+		}
+	}
+
+	stray, err := strayFiles(plan.Path.ValueString(), hashes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return hashes, stray, nil
+}
+
+func renderKustomization(labels types.Map, resources []string) ([]byte, error) {
+	k := map[string]interface{}{
+		"apiVersion": "kustomize.config.k8s.io/v1beta1",
+		"kind":       "Kustomization",
+		"resources":  resources,
+	}
+	if len(labels.Elements()) > 0 {
+		l, err := tfMaptoMapStringString(labels)
+		if err != nil {
+			return nil, err
+		}
+		k["commonLabels"] = l
+	}
+	return yaml.Marshal(k)
+}
+
+// kustomizationDrift returns the current SHA-256 of the written files which still exist, and lists
+// the written files which are missing or modified along with the YAML files which were not written.
+func kustomizationDrift(dir string, written map[string]string) (map[string]string, []string, error) {
+	current := make(map[string]string, len(written))
+	var drift []string
+	for name, want := range written {
+		got, err := fileHash(fpath.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			drift = append(drift, name+" is missing")
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		current[name] = got
+		if got != want {
+			drift = append(drift, name+" was modified")
+		}
+	}
+	stray, err := strayFiles(dir, written)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, name := range stray {
+		drift = append(drift, name+" is not managed")
+	}
+	sort.Strings(drift)
+	return current, drift, nil
+}
+
+// strayFiles lists the YAML files of dir which are not in hashes, in order.
+func strayFiles(dir string, hashes map[string]string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stray []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !(strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml")) {
+			continue
+		}
+		if _, ok := hashes[name]; !ok {
+			stray = append(stray, name)
+		}
+	}
+	return stray, nil
+}
+
+func addStrayFilesWarning(diags *diag.Diagnostics, dir string, stray []string) {
+	if len(stray) == 0 {
+		return
+	}
+	diags.AddWarning(
+		"Kustomization directory has files not generated",
+		fmt.Sprintf("%s: %s. They are not listed in %s and are left untouched.", dir, strings.Join(stray, ", "), kustomizationFile),
+	)
+}
+
+// writtenFiles returns the SHA-256 of the files as last written, which are those of files for
+// states written before the private state was kept.
+func writtenFiles(ctx context.Context, private privateState, files types.Map) (map[string]string, diag.Diagnostics) {
+	content, diags := private.GetKey(ctx, writtenFilesKey)
+	if diags.HasError() {
+		return nil, diags
+	}
+	if content == nil {
+		hashes, err := tfMaptoMapStringString(files)
+		if err != nil {
+			diags.AddError("Failed to convert files tf map to map[string]string", err.Error())
+		}
+		return hashes, diags
+	}
+	var hashes map[string]string
+	if err := json.Unmarshal(content, &hashes); err != nil {
+		diags.AddError("Failed to read private state", err.Error())
+	}
+	return hashes, diags
+}
+
+func setWrittenFiles(ctx context.Context, private privateState, hashes map[string]string) diag.Diagnostics {
+	content, err := json.Marshal(hashes)
+	if err != nil {
+		var diags diag.Diagnostics
+		diags.AddError("Failed to write private state", err.Error())
+		return diags
+	}
+	return private.SetKey(ctx, writtenFilesKey, content)
+}
+
+// writeFileIfChanged writes content to filePath unless the file already holds it, and returns its
+// SHA-256.
+func writeFileIfChanged(filePath string, content []byte) (string, error) {
+	if current, err := os.ReadFile(filePath); err == nil && bytes.Equal(current, content) {
+		return hashBytes(content), nil
+	}
+	if err := os.WriteFile(filePath, content, 0o644); err != nil {
+		return "", err
+	}
+	return hashBytes(content), nil
+}
+
+func fileHash(filePath string) (string, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	return hashBytes(content), nil
+}
+
+func hashBytes(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hashesToMap(hashes map[string]string) types.Map {
+	elems := make(map[string]attr.Value, len(hashes))
+	for k, v := range hashes {
+		elems[k] = types.StringValue(v)
+	}
+	return types.MapValueMust(types.StringType, elems)
+}
@@ -0,0 +1,180 @@
+package provider
+
+import (
+	"context"
+	"os"
+	fpath "path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		if err := os.WriteFile(fpath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func testBundleEntry(name string, stringData map[string]string) sealedSecretBundleEntryModel {
+	elems := make(map[string]attr.Value, len(stringData))
+	for k, v := range stringData {
+		elems[k] = types.StringValue(v)
+	}
+	null := types.MapNull(types.StringType)
+	return sealedSecretBundleEntryModel{
+		Name:                    types.StringValue(name),
+		Namespace:               types.StringValue("ns-aa"),
+		Scope:                   types.StringValue(defaultScope),
+		SecretType:              types.StringNull(),
+		StringData:              types.MapValueMust(types.StringType, elems),
+		Data:                    null,
+		DataBase64:              null,
+		Labels:                  null,
+		Annotations:             null,
+		SealedSecretLabels:      null,
+		SealedSecretAnnotations: null,
+	}
+}
+
+func TestWriteFileIfChanged(t *testing.T) {
+	filePath := fpath.Join(t.TempDir(), "file.yaml")
+
+	hash, err := writeFileIfChanged(filePath, []byte("contentAA"))
+	assert.Nil(t, err)
+	assert.Equal(t, hashBytes([]byte("contentAA")), hash)
+
+	// unchanged content is not written again
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	assert.Nil(t, os.Chtimes(filePath, past, past))
+	hash, err = writeFileIfChanged(filePath, []byte("contentAA"))
+	assert.Nil(t, err)
+	assert.Equal(t, hashBytes([]byte("contentAA")), hash)
+	info, err := os.Stat(filePath)
+	assert.Nil(t, err)
+	assert.Equal(t, past, info.ModTime())
+
+	hash, err = writeFileIfChanged(filePath, []byte("contentBB"))
+	assert.Nil(t, err)
+	assert.Equal(t, hashBytes([]byte("contentBB")), hash)
+	content, err := os.ReadFile(filePath)
+	assert.Nil(t, err)
+	assert.Equal(t, "contentBB", string(content))
+
+	_, err = writeFileIfChanged(fpath.Join(filePath, "file.yaml"), []byte("contentAA"))
+	assert.NotNil(t, err)
+}
+
+func TestStrayFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"kustomization.yaml": "",
+		"ns-aa_name-aa.yaml": "",
+		"patch.yml":          "",
+		"other.yaml":         "",
+		"README.md":          "",
+	})
+	assert.Nil(t, os.Mkdir(fpath.Join(dir, "base.yaml"), 0o755))
+
+	stray, err := strayFiles(dir, map[string]string{"kustomization.yaml": "", "ns-aa_name-aa.yaml": ""})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"other.yaml", "patch.yml"}, stray)
+
+	stray, err = strayFiles(fpath.Join(dir, "missing"), nil)
+	assert.Nil(t, err)
+	assert.Nil(t, stray)
+}
+
+func TestKustomizationDrift(t *testing.T) {
+	tests := []struct {
+		Name            string
+		Files           map[string]string
+		Written         map[string]string
+		ExpectedCurrent map[string]string
+		ExpectedDrift   []string
+	}{
+		{
+			Name:            "no drift",
+			Files:           map[string]string{"kustomization.yaml": "contentAA", "README.md": "contentBB"},
+			Written:         map[string]string{"kustomization.yaml": hashBytes([]byte("contentAA"))},
+			ExpectedCurrent: map[string]string{"kustomization.yaml": hashBytes([]byte("contentAA"))},
+		},
+		{
+			Name:  "modified, missing and not managed",
+			Files: map[string]string{"kustomization.yaml": "contentZZ", "other.yaml": "contentBB"},
+			Written: map[string]string{
+				"kustomization.yaml": hashBytes([]byte("contentAA")),
+				"ns-aa_name-aa.yaml": hashBytes([]byte("contentCC")),
+			},
+			ExpectedCurrent: map[string]string{"kustomization.yaml": hashBytes([]byte("contentZZ"))},
+			ExpectedDrift: []string{
+				"kustomization.yaml was modified",
+				"ns-aa_name-aa.yaml is missing",
+				"other.yaml is not managed",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestFiles(t, dir, tc.Files)
+
+			current, drift, err := kustomizationDrift(dir, tc.Written)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.ExpectedCurrent, current)
+			assert.Equal(t, tc.ExpectedDrift, drift)
+		})
+	}
+}
+
+func TestWriteKustomization(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"other.yaml": "contentAA"})
+
+	plan := sealedSecretKustomizationModel{
+		Path:         types.StringValue(dir),
+		CommonLabels: types.MapNull(types.StringType),
+		PublicKey:    types.StringValue(testCertPEM(t)),
+		Secrets: []sealedSecretBundleEntryModel{
+			testBundleEntry("name-aa", map[string]string{"keyAA": "valueAA"}),
+			testBundleEntry("name-bb", map[string]string{"keyBB": "valueBB"}),
+		},
+	}
+
+	// creating into an existing directory leaves its files alone
+	written, stray, err := writeKustomization(ctx, &plan, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"other.yaml"}, stray)
+	assert.Len(t, written, 3)
+	content, err := os.ReadFile(fpath.Join(dir, "other.yaml"))
+	assert.Nil(t, err)
+	assert.Equal(t, "contentAA", string(content))
+
+	// only the modified file is sealed again
+	writeTestFiles(t, dir, map[string]string{"ns-aa_name-aa.yaml": "modified"})
+	prior := plan
+	hashes, _, err := writeKustomization(ctx, &plan, &prior, written)
+	assert.Nil(t, err)
+	assert.NotEqual(t, written["ns-aa_name-aa.yaml"], hashes["ns-aa_name-aa.yaml"])
+	assert.NotEqual(t, hashBytes([]byte("modified")), hashes["ns-aa_name-aa.yaml"])
+	assert.Equal(t, written["ns-aa_name-bb.yaml"], hashes["ns-aa_name-bb.yaml"])
+	assert.Equal(t, written[kustomizationFile], hashes[kustomizationFile])
+
+	// only written files no longer generated are removed
+	plan.Secrets = plan.Secrets[:1]
+	written = hashes
+	hashes, stray, err = writeKustomization(ctx, &plan, &prior, written)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"other.yaml"}, stray)
+	assert.Len(t, hashes, 2)
+	_, err = os.Stat(fpath.Join(dir, "ns-aa_name-bb.yaml"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(fpath.Join(dir, "other.yaml"))
+	assert.Nil(t, err)
+}
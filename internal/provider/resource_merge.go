@@ -0,0 +1,211 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/k8s"
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/kubeseal"
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const manifest = "manifest"
+
+type sealedSecretMergeResource struct {
+	provider *providerData
+}
+
+type sealedSecretMergeModel struct {
+	Manifest     types.String `tfsdk:"manifest"`
+	Name         types.String `tfsdk:"name"`
+	Namespace    types.String `tfsdk:"namespace"`
+	Scope        types.String `tfsdk:"scope"`
+	StringData   types.Map    `tfsdk:"string_data"`
+	Data         types.Map    `tfsdk:"data"`
+	PublicKey    types.String `tfsdk:"public_key"`
+	Cluster      types.String `tfsdk:"cluster"`
+	SealedSecret types.String `tfsdk:"sealed_secret"`
+}
+
+func NewSealedSecretMergeResource() resource.Resource {
+	return &sealedSecretMergeResource{}
+}
+
+func (r *sealedSecretMergeResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = "sealedsecret_merge"
+}
+
+func (r *sealedSecretMergeResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	publicKeyAttr, clusterAttr := publicKeyAttributes("The public key of the controller the manifest was sealed for.")
+	resp.Schema = schema.Schema{
+		Description: "Seals additional keys and merges them into an existing SealedSecret manifest, like kubeseal --merge-into. Keys not given keep their ciphertext byte-identical",
+		Attributes: map[string]schema.Attribute{
+			manifest: schema.StringAttribute{
+				Required:    true,
+				Description: "The existing SealedSecret manifest (YAML or JSON) to merge into",
+			},
+			name: schema.StringAttribute{
+				Optional:    true,
+				Description: "Expected name of the sealed secret, the merge is rejected if the manifest differs",
+			},
+			namespace: schema.StringAttribute{
+				Optional:    true,
+				Description: "Expected namespace of the sealed secret, the merge is rejected if the manifest differs",
+			},
+			scope: schema.StringAttribute{
+				Optional: true,
+				Validators: []validator.String{
+					scopeValidator(),
+				},
+				Description: "Expected scope of the sealed secret: strict, namespace-wide, cluster-wide. The merge is rejected if the manifest differs",
+			},
+			data: schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Sensitive:   true,
+				Description: "Key/value pairs to merge into the sealed secret. The value will be base64 encoded",
+			},
+			stringData: schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Sensitive:   true,
+				Description: "Key/value pairs to merge into the sealed secret.",
+			},
+			publicKey: publicKeyAttr,
+			clusters:  clusterAttr,
+			"sealed_secret": schema.StringAttribute{
+				Computed:    true,
+				Description: "The merged SealedSecret manifest. Only its encryptedData differs from manifest, which keeps its format, key order and comments.",
+			},
+		},
+	}
+}
+
+func (r *sealedSecretMergeResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.provider = resourceProviderData(req, resp)
+}
+
+func (r *sealedSecretMergeResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	modifyPlanPublicKey(ctx, r.provider, req, resp, path.Root("sealed_secret"))
+}
+
+func (r *sealedSecretMergeResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	tflog.Debug(ctx, "Create sealed secret merge resource")
+	var plan sealedSecretMergeModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx = maskSecretValues(ctx, mapValues(plan.Data, plan.StringData))
+
+	sealedSecret, err := mergeSealedSecret(ctx, &plan)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to merge sealed secret", err.Error())
+		return
+	}
+
+	plan.SealedSecret = types.StringValue(string(sealedSecret))
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *sealedSecretMergeResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	tflog.Debug(ctx, "Read sealed secret merge resource")
+}
+
+func (r *sealedSecretMergeResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	tflog.Debug(ctx, "Update sealed secret merge resource")
+	var plan sealedSecretMergeModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx = maskSecretValues(ctx, mapValues(plan.Data, plan.StringData))
+
+	sealedSecret, err := mergeSealedSecret(ctx, &plan)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to merge sealed secret", err.Error())
+		return
+	}
+
+	plan.SealedSecret = types.StringValue(string(sealedSecret))
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *sealedSecretMergeResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	tflog.Debug(ctx, "Delete sealed secret merge resource")
+}
+
+func mergeSealedSecret(ctx context.Context, plan *sealedSecretMergeModel) ([]byte, error) {
+	base, err := kubeseal.ParseSealedSecret([]byte(plan.Manifest.ValueString()))
+	if err != nil {
+		return nil, err
+	}
+
+	baseScope := base.Scope()
+	if !plan.Name.IsNull() && plan.Name.ValueString() != base.Name {
+		return nil, fmt.Errorf("name %q does not match the manifest name %q", plan.Name.ValueString(), base.Name)
+	}
+	if !plan.Namespace.IsNull() && plan.Namespace.ValueString() != base.Namespace {
+		return nil, fmt.Errorf("namespace %q does not match the manifest namespace %q", plan.Namespace.ValueString(), base.Namespace)
+	}
+	if !plan.Scope.IsNull() && plan.Scope.ValueString() != baseScope.String() {
+		return nil, fmt.Errorf("scope %q does not match the manifest scope %q", plan.Scope.ValueString(), baseScope.String())
+	}
+
+	data, err := tfMaptoMapStringString(plan.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert data tf map to map[string]string: %w", err)
+	}
+	stringData, err := tfMaptoMapStringString(plan.StringData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert stringdata tf map to map[string]string: %w", err)
+	}
+	for attr, m := range map[string]map[string]string{"data": data, "string_data": stringData} {
+		for k := range m {
+			if err := k8s.ValidateKey(k); err != nil {
+				return nil, fmt.Errorf("%s: %w", attr, err)
+			}
+		}
+	}
+	for k := range stringData {
+		if _, ok := data[k]; ok {
+			return nil, fmt.Errorf("key %q is set in both data and string_data", k)
+		}
+	}
+
+	// Only the ciphertext of the new keys is used, which does not depend on the secret type, and
+	// the keys alone would not pass the checks for the manifest's type.
+	rawSecret := k8s.SecretManifest{
+		Name:        base.Name,
+		Namespace:   base.Namespace,
+		Type:        "Opaque",
+		Annotations: ssv1alpha1.UpdateScopeAnnotations(nil, baseScope),
+		Data:        make(map[string]interface{}, len(data)),
+		StringData:  stringData,
+	}
+	for k, v := range data {
+		rawSecret.Data[k] = v
+	}
+
+	secret, err := k8s.CreateSecret(&rawSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	pk, err := kubeseal.ParsePublicKey([]byte(plan.PublicKey.ValueString()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	return kubeseal.NewSealer(kubeseal.StaticKey(pk), kubeseal.WithMergeBase([]byte(plan.Manifest.ValueString()))).Seal(ctx, secret)
+}
@@ -0,0 +1,166 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/fakecontroller"
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/kubeseal"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSealedSecretMergeCreate(t *testing.T) {
+	ctx := context.Background()
+	controller, err := fakecontroller.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer controller.Close()
+	pk, err := kubeseal.ParsePublicKey(controller.CertPEM())
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, err := kubeseal.NewSealer(kubeseal.StaticKey(pk)).Seal(ctx, v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "name-aa", Namespace: "ns-aa"},
+		StringData: map[string]string{"keyAA": "valueAA"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stringMap := func(values map[string]string) types.Map {
+		if values == nil {
+			return types.MapNull(types.StringType)
+		}
+		elems := make(map[string]attr.Value, len(values))
+		for k, v := range values {
+			elems[k] = types.StringValue(v)
+		}
+		return types.MapValueMust(types.StringType, elems)
+	}
+	model := func(modify func(m *sealedSecretMergeModel)) sealedSecretMergeModel {
+		m := sealedSecretMergeModel{
+			Manifest:     types.StringValue(string(base)),
+			Name:         types.StringNull(),
+			Namespace:    types.StringNull(),
+			Scope:        types.StringNull(),
+			StringData:   stringMap(map[string]string{"keyBB": "valueBB"}),
+			Data:         stringMap(map[string]string{"keyCC": "valueCC"}),
+			PublicKey:    types.StringValue(string(controller.CertPEM())),
+			Cluster:      types.StringNull(),
+			SealedSecret: types.StringUnknown(),
+		}
+		if modify != nil {
+			modify(&m)
+		}
+		return m
+	}
+
+	tests := []struct {
+		Name          string
+		Plan          sealedSecretMergeModel
+		ExpectedData  map[string][]byte
+		ExpectedError string
+	}{
+		{
+			Name: "merged",
+			Plan: model(func(m *sealedSecretMergeModel) {
+				m.Name, m.Namespace, m.Scope = types.StringValue("name-aa"), types.StringValue("ns-aa"), types.StringValue("strict")
+			}),
+			ExpectedData: map[string][]byte{
+				"keyAA": []byte("valueAA"),
+				"keyBB": []byte("valueBB"),
+				"keyCC": []byte("valueCC"),
+			},
+		},
+		{
+			Name: "invalid manifest",
+			Plan: model(func(m *sealedSecretMergeModel) {
+				m.Manifest = types.StringValue("kind: Secret")
+			}),
+			ExpectedError: "unable to decode sealed secret manifest",
+		},
+		{
+			Name: "name mismatch",
+			Plan: model(func(m *sealedSecretMergeModel) {
+				m.Name = types.StringValue("name-bb")
+			}),
+			ExpectedError: `name "name-bb" does not match the manifest name "name-aa"`,
+		},
+		{
+			Name: "namespace mismatch",
+			Plan: model(func(m *sealedSecretMergeModel) {
+				m.Namespace = types.StringValue("ns-bb")
+			}),
+			ExpectedError: `namespace "ns-bb" does not match the manifest namespace "ns-aa"`,
+		},
+		{
+			Name: "scope mismatch",
+			Plan: model(func(m *sealedSecretMergeModel) {
+				m.Scope = types.StringValue("cluster-wide")
+			}),
+			ExpectedError: `scope "cluster-wide" does not match the manifest scope "strict"`,
+		},
+		{
+			Name: "invalid key",
+			Plan: model(func(m *sealedSecretMergeModel) {
+				m.Data = stringMap(map[string]string{"key/AA": "valueAA"})
+			}),
+			ExpectedError: `data: invalid key "key/AA"`,
+		},
+		{
+			Name: "key in data and string_data",
+			Plan: model(func(m *sealedSecretMergeModel) {
+				m.Data = stringMap(map[string]string{"keyBB": "valueAA"})
+			}),
+			ExpectedError: `key "keyBB" is set in both data and string_data`,
+		},
+		{
+			Name: "too large",
+			Plan: model(func(m *sealedSecretMergeModel) {
+				m.StringData = stringMap(map[string]string{"keyBB": strings.Repeat("a", kubeseal.MaxEncryptedDataSize)})
+			}),
+			ExpectedError: "more than the",
+		},
+	}
+
+	r := &sealedSecretMergeResource{}
+	var schemaResp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			plan := tfsdk.Plan{Schema: schemaResp.Schema}
+			if diags := plan.Set(ctx, &tc.Plan); diags.HasError() {
+				t.Fatal(diags)
+			}
+			resp := resource.CreateResponse{State: tfsdk.State{
+				Schema: schemaResp.Schema,
+				Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+			}}
+			r.Create(ctx, resource.CreateRequest{Plan: plan}, &resp)
+
+			if tc.ExpectedError != "" {
+				assert.True(t, resp.Diagnostics.HasError())
+				for _, d := range resp.Diagnostics.Errors() {
+					assert.Equal(t, "Failed to merge sealed secret", d.Summary())
+					assert.Contains(t, d.Detail(), tc.ExpectedError)
+				}
+				return
+			}
+			assert.False(t, resp.Diagnostics.HasError(), resp.Diagnostics)
+			var state sealedSecretMergeModel
+			resp.Diagnostics.Append(resp.State.Get(ctx, &state)...)
+			secret, err := controller.Unseal([]byte(state.SealedSecret.ValueString()))
+			assert.Nil(t, err)
+			assert.Equal(t, tc.ExpectedData, secret.Data)
+		})
+	}
+}
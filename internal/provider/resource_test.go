@@ -0,0 +1,361 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetControllerAnnotations(t *testing.T) {
+	null := types.BoolNull()
+	tests := []struct {
+		Name                   string
+		Annotations            map[string]string
+		Managed                types.Bool
+		Patch                  types.Bool
+		SkipSetOwnerReferences types.Bool
+		ExpectedAnnotations    map[string]string
+		ExpectedErr            string
+	}{
+		{
+			Name:                   "none set",
+			Annotations:            map[string]string{},
+			Managed:                null,
+			Patch:                  null,
+			SkipSetOwnerReferences: null,
+			ExpectedAnnotations:    map[string]string{},
+		},
+		{
+			Name:                   "all true",
+			Annotations:            map[string]string{},
+			Managed:                types.BoolValue(true),
+			Patch:                  types.BoolValue(true),
+			SkipSetOwnerReferences: types.BoolValue(true),
+			ExpectedAnnotations: map[string]string{
+				managedAnnotation:                "true",
+				patchAnnotation:                  "true",
+				skipSetOwnerReferencesAnnotation: "true",
+			},
+		},
+		{
+			Name:                   "false is not written",
+			Annotations:            map[string]string{},
+			Managed:                types.BoolValue(false),
+			Patch:                  types.BoolValue(true),
+			SkipSetOwnerReferences: types.BoolValue(false),
+			ExpectedAnnotations:    map[string]string{patchAnnotation: "true"},
+		},
+		{
+			Name:                   "matching annotation",
+			Annotations:            map[string]string{managedAnnotation: "false"},
+			Managed:                types.BoolValue(false),
+			Patch:                  null,
+			SkipSetOwnerReferences: types.BoolValue(true),
+			ExpectedAnnotations: map[string]string{
+				managedAnnotation:                "false",
+				skipSetOwnerReferencesAnnotation: "true",
+			},
+		},
+		{
+			Name:                   "annotation kept when attribute is null",
+			Annotations:            map[string]string{patchAnnotation: "true"},
+			Managed:                null,
+			Patch:                  null,
+			SkipSetOwnerReferences: null,
+			ExpectedAnnotations:    map[string]string{patchAnnotation: "true"},
+		},
+		{
+			Name:                   "conflict",
+			Annotations:            map[string]string{skipSetOwnerReferencesAnnotation: "true"},
+			Managed:                null,
+			Patch:                  null,
+			SkipSetOwnerReferences: types.BoolValue(false),
+			ExpectedErr:            `annotation sealedsecrets.bitnami.com/skip-set-owner-references is set to "true" but the matching attribute is false`,
+		},
+		{
+			Name: "first conflict is reported",
+			Annotations: map[string]string{
+				managedAnnotation:                "false",
+				patchAnnotation:                  "false",
+				skipSetOwnerReferencesAnnotation: "false",
+			},
+			Managed:                types.BoolValue(true),
+			Patch:                  types.BoolValue(true),
+			SkipSetOwnerReferences: types.BoolValue(true),
+			ExpectedErr:            `annotation sealedsecrets.bitnami.com/managed is set to "false" but the matching attribute is true`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := setControllerAnnotations(tc.Annotations, tc.Managed, tc.Patch, tc.SkipSetOwnerReferences)
+			if tc.ExpectedErr != "" {
+				assert.EqualError(t, err, tc.ExpectedErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.ExpectedAnnotations, tc.Annotations)
+		})
+	}
+}
+func TestPublicKeyFingerprintIgnoresFormatting(t *testing.T) {
+	certAA := testCertPEM(t)
+	expected, err := publicKeyFingerprint(certAA)
+	assert.Nil(t, err)
+
+	lines := strings.Split(strings.TrimSpace(certAA), "\n")
+	body := strings.Join(lines[1:len(lines)-1], "")
+	reformatted := []string{
+		"\n\n" + certAA + "\n\n",
+		strings.ReplaceAll(certAA, "\n", "\r\n"),
+		lines[0] + "\n" + body + "\n" + lines[len(lines)-1] + "\n",
+		strings.TrimSuffix(certAA, "\n"),
+	}
+	for _, pem := range reformatted {
+		fingerprint, err := publicKeyFingerprint(pem)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, fingerprint)
+	}
+
+	other, err := publicKeyFingerprint(testCertPEM(t))
+	assert.Nil(t, err)
+	assert.NotEqual(t, expected, other)
+
+	_, err = publicKeyFingerprint("not a certificate")
+	assert.ErrorContains(t, err, "failed to parse public key")
+}
+
+func TestModifyPlanPublicKeyHash(t *testing.T) {
+	certAA, certBB := testCertPEM(t), testCertPEM(t)
+	fingerprintAA, err := publicKeyFingerprint(certAA)
+	assert.Nil(t, err)
+	fingerprintBB, err := publicKeyFingerprint(certBB)
+	assert.Nil(t, err)
+
+	tests := []struct {
+		Name                  string
+		PublicKey             types.String
+		PriorPublicKey        types.String
+		PriorPublicKeyHash    types.String
+		ExpectedPublicKeyHash types.String
+		ExpectedWarning       bool
+		ExpectedError         bool
+	}{
+		{
+			Name:                  "same key",
+			PublicKey:             types.StringValue(certAA),
+			PriorPublicKey:        types.StringValue(certAA),
+			PriorPublicKeyHash:    types.StringValue(fingerprintAA),
+			ExpectedPublicKeyHash: types.StringValue(fingerprintAA),
+		},
+		{
+			Name:                  "same key formatted differently",
+			PublicKey:             types.StringValue(strings.ReplaceAll(certAA, "\n", "\r\n")),
+			PriorPublicKey:        types.StringValue(certAA),
+			PriorPublicKeyHash:    types.StringValue(fingerprintAA),
+			ExpectedPublicKeyHash: types.StringValue(fingerprintAA),
+		},
+		{
+			Name:                  "key changed",
+			PublicKey:             types.StringValue(certBB),
+			PriorPublicKey:        types.StringValue(certAA),
+			PriorPublicKeyHash:    types.StringValue(fingerprintAA),
+			ExpectedPublicKeyHash: types.StringValue(fingerprintBB),
+			ExpectedWarning:       true,
+		},
+		{
+			Name:                  "state written before public_key_hash",
+			PublicKey:             types.StringValue(certBB),
+			PriorPublicKey:        types.StringValue(certAA),
+			PriorPublicKeyHash:    types.StringNull(),
+			ExpectedPublicKeyHash: types.StringValue(fingerprintBB),
+			ExpectedWarning:       true,
+		},
+		{
+			Name:                  "moved state without key",
+			PublicKey:             types.StringValue(certBB),
+			PriorPublicKey:        types.StringNull(),
+			PriorPublicKeyHash:    types.StringNull(),
+			ExpectedPublicKeyHash: types.StringValue(fingerprintBB),
+		},
+		{
+			Name:                  "unknown key",
+			PublicKey:             types.StringUnknown(),
+			PriorPublicKey:        types.StringValue(certAA),
+			PriorPublicKeyHash:    types.StringValue(fingerprintAA),
+			ExpectedPublicKeyHash: types.StringUnknown(),
+		},
+		{
+			Name:               "invalid key",
+			PublicKey:          types.StringValue("not a certificate"),
+			PriorPublicKey:     types.StringValue(certAA),
+			PriorPublicKeyHash: types.StringValue(fingerprintAA),
+			ExpectedError:      true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := context.Background()
+			planned := sealedSecretState(ctx, t, map[string]attr.Value{publicKey: tc.PublicKey})
+			plan := tfsdk.Plan{Schema: planned.Schema, Raw: planned.Raw}
+			state := sealedSecretState(ctx, t, map[string]attr.Value{
+				publicKey:     tc.PriorPublicKey,
+				publicKeyHash: tc.PriorPublicKeyHash,
+			})
+
+			resp := resource.ModifyPlanResponse{Plan: plan}
+			(&sealedSecretResource{}).modifyPlanPublicKeyHash(ctx, resource.ModifyPlanRequest{Plan: plan, State: state}, &resp)
+			assert.Equal(t, tc.ExpectedError, resp.Diagnostics.HasError(), resp.Diagnostics)
+			if tc.ExpectedError {
+				return
+			}
+			assert.Equal(t, tc.ExpectedWarning, resp.Diagnostics.WarningsCount() == 1)
+
+			var hash types.String
+			resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root(publicKeyHash), &hash)...)
+			assert.Equal(t, tc.ExpectedPublicKeyHash, hash)
+		})
+	}
+}
+
+func TestModifyPlanSealedSecret(t *testing.T) {
+	certAA, certBB := testCertPEM(t), testCertPEM(t)
+	fingerprintAA, err := publicKeyFingerprint(certAA)
+	assert.Nil(t, err)
+	fingerprintBB, err := publicKeyFingerprint(certBB)
+	assert.Nil(t, err)
+	dataOf := func(value string) types.Map {
+		return types.MapValueMust(types.StringType, map[string]attr.Value{"keyAA": types.StringValue(value)})
+	}
+	prior := func() map[string]attr.Value {
+		return map[string]attr.Value{
+			name:            types.StringValue("name-aa"),
+			namespace:       types.StringValue("ns-aa"),
+			scope:           types.StringValue(defaultScope),
+			secretType:      types.StringValue("Opaque"),
+			data:            dataOf("valueAA"),
+			publicKey:       types.StringValue(certAA),
+			publicKeyHash:   types.StringValue(fingerprintAA),
+			stale:           types.BoolValue(false),
+			"sealed_secret": types.StringValue("sealed-secret"),
+		}
+	}
+
+	tests := []struct {
+		Name                 string
+		State                map[string]attr.Value
+		Plan                 map[string]attr.Value
+		ExpectedSealedSecret types.String
+		ExpectedStale        types.Bool
+	}{
+		{
+			Name:                 "unchanged",
+			ExpectedSealedSecret: types.StringValue("sealed-secret"),
+			ExpectedStale:        types.BoolValue(false),
+		},
+		{
+			Name: "only the formatting of public_key changed",
+			Plan: map[string]attr.Value{
+				publicKey: types.StringValue(strings.ReplaceAll(certAA, "\n", "\r\n")),
+			},
+			ExpectedSealedSecret: types.StringValue("sealed-secret"),
+			ExpectedStale:        types.BoolValue(false),
+		},
+		{
+			Name: "key changed",
+			Plan: map[string]attr.Value{
+				publicKey:     types.StringValue(certBB),
+				publicKeyHash: types.StringValue(fingerprintBB),
+			},
+			ExpectedSealedSecret: types.StringUnknown(),
+			ExpectedStale:        types.BoolUnknown(),
+		},
+		{
+			Name: "scope null in a state written before its default",
+			State: map[string]attr.Value{
+				scope: types.StringNull(),
+			},
+			ExpectedSealedSecret: types.StringValue("sealed-secret"),
+			ExpectedStale:        types.BoolValue(false),
+		},
+		{
+			Name: "scope changed",
+			Plan: map[string]attr.Value{
+				scope: types.StringValue("cluster-wide"),
+			},
+			ExpectedSealedSecret: types.StringUnknown(),
+			ExpectedStale:        types.BoolUnknown(),
+		},
+		{
+			Name: "data changed",
+			Plan: map[string]attr.Value{
+				data: dataOf("valueBB"),
+			},
+			ExpectedSealedSecret: types.StringUnknown(),
+			ExpectedStale:        types.BoolUnknown(),
+		},
+		{
+			Name: "cluster changed with the same key",
+			State: map[string]attr.Value{
+				publicKey: types.StringValue(certAA),
+				clusters:  types.StringValue("clusterAA"),
+			},
+			Plan: map[string]attr.Value{
+				publicKey: types.StringValue(certAA),
+				clusters:  types.StringValue("clusterBB"),
+			},
+			ExpectedSealedSecret: types.StringValue("sealed-secret"),
+			ExpectedStale:        types.BoolValue(false),
+		},
+		{
+			Name: "cluster changed to another key",
+			State: map[string]attr.Value{
+				clusters: types.StringValue("clusterAA"),
+			},
+			Plan: map[string]attr.Value{
+				publicKey:     types.StringValue(certBB),
+				publicKeyHash: types.StringValue(fingerprintBB),
+				clusters:      types.StringValue("clusterBB"),
+			},
+			ExpectedSealedSecret: types.StringUnknown(),
+			ExpectedStale:        types.BoolUnknown(),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := context.Background()
+			stateAttrs, planAttrs := prior(), prior()
+			for k, v := range tc.State {
+				stateAttrs[k] = v
+			}
+			for k, v := range tc.Plan {
+				planAttrs[k] = v
+			}
+			// sealed_secret and stale are computed, the framework plans them unknown on any change
+			planAttrs["sealed_secret"], planAttrs[stale] = types.StringUnknown(), types.BoolUnknown()
+			state := sealedSecretState(ctx, t, stateAttrs)
+			planned := sealedSecretState(ctx, t, planAttrs)
+			plan := tfsdk.Plan{Schema: planned.Schema, Raw: planned.Raw}
+
+			resp := resource.ModifyPlanResponse{Plan: plan}
+			(&sealedSecretResource{}).modifyPlanSealedSecret(ctx, resource.ModifyPlanRequest{Plan: plan, State: state}, &resp)
+			assert.False(t, resp.Diagnostics.HasError(), resp.Diagnostics)
+
+			var sealedSecret types.String
+			var isStale types.Bool
+			resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root("sealed_secret"), &sealedSecret)...)
+			resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root(stale), &isStale)...)
+			assert.Equal(t, tc.ExpectedSealedSecret, sealedSecret)
+			assert.Equal(t, tc.ExpectedStale, isStale)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// SealResourceJSON seals a secret outside of Terraform. arguments is a JSON object holding the
+// arguments of a sealedsecret resource, e.g. {"name": "db", "namespace": "app", "string_data": {...}},
+// missing arguments are null. Defaults are applied like during a plan, so the output is what the
+// resource would store in sealed_secret. publicKey, when not empty, replaces the public_key
+// argument.
+func SealResourceJSON(ctx context.Context, arguments []byte, publicKey string) ([]byte, error) {
+	var schemaResp resource.SchemaResponse
+	(&sealedSecretResource{}).Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+
+	raw, err := tftypes.ValueFromJSON(arguments, schemaResp.Schema.Type().TerraformType(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource arguments: %w", err)
+	}
+	config := tfsdk.Config{Schema: schemaResp.Schema, Raw: raw}
+
+	var plan sealedSecretModel
+	if diags := config.Get(ctx, &plan); diags.HasError() {
+		return nil, diagnosticsError(diags)
+	}
+
+	if publicKey != "" {
+		plan.PublicKey = types.StringValue(publicKey)
+	}
+	if plan.PublicKey.IsNull() && !plan.Cluster.IsNull() {
+		return nil, fmt.Errorf("cluster %q is only known to the provider configuration, give its certificate instead", plan.Cluster.ValueString())
+	}
+	if plan.PublicKey.IsNull() {
+		return nil, fmt.Errorf("public_key is required")
+	}
+	if plan.SecretType.IsNull() {
+		if len(plan.DockerRegistries) > 0 {
+			plan.SecretType = types.StringValue("kubernetes.io/dockerconfigjson")
+		} else {
+			plan.SecretType = types.StringValue("Opaque")
+		}
+	}
+
+	return createSealedSecret(ctx, &plan)
+}
+
+func diagnosticsError(diags diag.Diagnostics) error {
+	var msgs []string
+	for _, d := range diags.Errors() {
+		msgs = append(msgs, d.Summary()+": "+d.Detail())
+	}
+	return fmt.Errorf("%s", strings.Join(msgs, "; "))
+}
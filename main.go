@@ -2,6 +2,9 @@ package main
 
 import (
 	"context"
+	"os"
+
+	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/cli"
 	"github.com/AdamJacobMuller/terraform-provider-sealedsecret/internal/provider"
 
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
@@ -11,6 +14,11 @@ import (
 //go:generate go run github.com/hashicorp/terraform-plugin-docs/cmd/tfplugindocs generate --provider-name hashicups
 
 func main() {
+	// Terraform launches the provider without arguments, subcommands reproduce it for debugging.
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		os.Exit(cli.Run(context.Background(), os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+	}
+
 	err := providerserver.Serve(context.Background(), provider.New, providerserver.ServeOpts{
 		Address: "registry.terraform.io/adamjacobmuller/sealedsecret",
 	})